@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/aodb"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// aodb-stub plays the operational database of the airport: it sends a batch
+// of flight updates to the webhook of the server. The batch is read from a
+// JSON file ({"updates": [...]}) or made of a single update from the flags.
+func main() {
+	url := flag.String("url", "http://localhost:8080/aodb/flights", "webhook address")
+	token := flag.String("token", os.Getenv("AODB_TOKEN"), "webhook token")
+	path := flag.String("file", "", "path to a JSON batch of updates")
+	number := flag.String("number", "", "flight number")
+	direction := flag.String("direction", models.FlightArrival, "flight direction (A or D)")
+	date := flag.String("date", time.Now().Format("2006-01-02"), "date of the flight at its origin (2006-01-02)")
+	estimated := flag.String("estimated", "", "estimated time (2006-01-02 15:04)")
+	stand := flag.String("stand", "", "new stand")
+	gate := flag.String("gate", "", "new gate")
+	status := flag.String("status", "", "new status, e.g. cancelled")
+	flag.Parse()
+
+	var batch aodb.Batch
+	if *path != "" {
+		body, err := os.ReadFile(*path)
+		if err != nil {
+			log.Fatalf("failed to read batch: %s", err)
+		}
+		if err := json.Unmarshal(body, &batch); err != nil {
+			log.Fatalf("failed to decode batch: %s", err)
+		}
+	} else {
+		if *number == "" {
+			log.Fatal("file or number is not set")
+		}
+
+		update := models.FlightUpdate{
+			Number:    *number,
+			Direction: *direction,
+			Date:      *date,
+			Time:      time.Now(),
+		}
+		if *estimated != "" {
+			at, err := time.ParseInLocation("2006-01-02 15:04", *estimated, time.Local)
+			if err != nil {
+				log.Fatalf("wrong estimated: %s", err)
+			}
+			update.EstimatedTime = &at
+		}
+		if *stand != "" {
+			update.Stand = stand
+		}
+		if *gate != "" {
+			update.Gate = gate
+		}
+		if *status != "" {
+			update.Status = status
+		}
+		batch.Updates = []models.FlightUpdate{update}
+	}
+
+	client := aodb.Client{URL: *url, Token: *token}
+	code, answer, err := client.Send(batch)
+	if err != nil {
+		log.Fatalf("failed to send batch: %s", err)
+	}
+
+	log.Printf("%d %s: %s", code, http.StatusText(code), answer)
+}
@@ -8,8 +8,11 @@ import (
 	"time"
 
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/config"
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	graphstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/graph-storage/postgresql"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/slogpretty"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/scheduler"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/server"
 	"golang.org/x/exp/slog"
@@ -21,12 +24,23 @@ const (
 	envProd  = "prod"
 
 	timeInterval = 30 // time between task generations in minutes
+	replanDelay  = 5  // seconds to collect flight changes before replanning them
 )
 
 func main() {
 	cfg := config.MustLoad()
 
-	sched, err := scheduler.New(cfg, timeInterval*time.Minute)
+	graphStorage, err := graphstorage.New(cfg.GS.Host, cfg.GS.Port, cfg.GS.User, cfg.GS.Password, cfg.GS.DBname)
+	if err != nil {
+		panic(err)
+	}
+
+	graph, err := distancegraph.NewHolder(graphStorage)
+	if err != nil {
+		panic(err)
+	}
+
+	sched, err := scheduler.New(cfg, graph, timeInterval*time.Minute)
 	if err != nil {
 		panic(err)
 	}
@@ -40,9 +54,10 @@ func main() {
 	)
 	log.Debug("debug messages are enabled")
 
-	srv, err := server.New(cfg, log)
+	srv, err := server.New(cfg, log, graph, sched)
 	if err != nil {
 		log.Error("failed to create server", sl.Err(err))
+		os.Exit(1)
 	}
 	log.Info("starting server", slog.String("address", cfg.HTTPServer.Address))
 
@@ -55,6 +70,20 @@ func main() {
 		}
 	}()
 
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	go func() {
+		for range reload {
+			log.Info("reloading graph")
+			if err := graph.Reload(); err != nil {
+				log.Error("failed to reload graph", sl.Err(err))
+			} else {
+				log.Info("graph reloaded")
+			}
+		}
+	}()
+
 	go func() {
 		for {
 			log.Info("Creating schedule")
@@ -69,10 +98,36 @@ func main() {
 		}
 	}()
 
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	flightStorage, err := flightstorage.New(cfg.FS.Host, cfg.FS.Port, cfg.FS.User, cfg.FS.Password, cfg.FS.DBname)
+	if err != nil {
+		panic(err)
+	}
+
+	changes, err := flightStorage.Changes(ctx)
+	if err != nil {
+		log.Error("failed to subscribe to flight changes", sl.Err(err))
+	} else {
+		go sched.Watch(ctx, changes, replanDelay*time.Second, func(diff scheduler.Diff, err error) {
+			if err != nil {
+				log.Error("failed to replan changed flights", sl.Err(err))
+				return
+			}
+			log.Info("changed flights replanned",
+				slog.Int("added", len(diff.Added)),
+				slog.Int("moved", len(diff.Moved)),
+				slog.Int("removed", len(diff.Removed)),
+			)
+		})
+	}
+
 	log.Info("server started")
 
 	<-done
 	log.Info("stopping server")
+	stop()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	accountstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/account-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/config"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// create-account adds a dispatcher or an admin account. It is used to create
+// the first admin, who then adds the other accounts through the API.
+func main() {
+	login := flag.String("login", "", "login of the account")
+	password := flag.String("password", "", "password of the account")
+	role := flag.String("role", models.RoleAdmin, "role of the account (dispatcher or admin)")
+	flag.Parse()
+
+	if *login == "" || *password == "" {
+		log.Fatal("login and password are required")
+	}
+	if *role != models.RoleDispatcher && *role != models.RoleAdmin {
+		log.Fatal("role must be dispatcher or admin")
+	}
+
+	cfg := config.MustLoad()
+
+	hash, err := auth.HashPassword(*password)
+	if err != nil {
+		log.Fatalf("failed to hash password: %s", err)
+	}
+
+	as, err := accountstorage.New(cfg.AS.Host, cfg.AS.Port, cfg.AS.User, cfg.AS.Password, cfg.AS.DBname)
+	if err != nil {
+		log.Fatalf("failed to connect to account storage: %s", err)
+	}
+
+	account, err := as.AddAccount(models.Account{Login: *login, Role: *role, PasswordHash: hash})
+	if err != nil {
+		log.Fatalf("failed to add account: %s", err)
+	}
+
+	log.Printf("added %s account %q with id %d", account.Role, account.Login, account.Id)
+}
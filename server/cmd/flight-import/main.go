@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/config"
+	filestorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/file"
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+)
+
+// flight-import reads a seasonal schedule from an SSIM or CSV file, expands
+// it into daily flights of the airport within the date range and adds them to
+// the database. Importing the same file again updates the flights instead of
+// duplicating them.
+func main() {
+	path := flag.String("file", "", "path to the schedule file (.csv or SSIM)")
+	airport := flag.String("airport", "", "IATA code of the airport, required for SSIM")
+	from := flag.String("from", time.Now().Format("2006-01-02"), "first day to import (2006-01-02)")
+	to := flag.String("to", time.Now().AddDate(0, 0, 7).Format("2006-01-02"), "last day to import (2006-01-02)")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("file is not set")
+	}
+
+	first, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+	if err != nil {
+		log.Fatalf("wrong from: %s", err)
+	}
+	last, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+	if err != nil {
+		log.Fatalf("wrong to: %s", err)
+	}
+	if last.Before(first) {
+		log.Fatal("to is before from")
+	}
+
+	cfg := config.MustLoad()
+
+	source, err := filestorage.New(*path, *airport, first, last)
+	if err != nil {
+		log.Fatalf("failed to read schedule: %s", err)
+	}
+	flights := source.Flights()
+
+	fs, err := flightstorage.New(cfg.FS.Host, cfg.FS.Port, cfg.FS.User, cfg.FS.Password, cfg.FS.DBname)
+	if err != nil {
+		log.Fatalf("failed to connect to flight storage: %s", err)
+	}
+
+	added, err := fs.UpsertFlights(flights)
+	if err != nil {
+		log.Fatalf("failed to import flights: %s", err)
+	}
+
+	log.Printf("imported %d flights: %d added, %d updated", len(flights), added, len(flights)-added)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/config"
+	filestorage "github.com/GrishaSkurikhin/Aviahackathon/internal/graph-storage/file"
+	graphstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/graph-storage/postgresql"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+// graph-import loads the airport graph and its restrictions from a YAML or
+// CSV file, checks that every vertex is reachable from every other one and
+// replaces the graph in the database.
+func main() {
+	path := flag.String("file", "", "path to the graph file (.yaml, .yml or .csv)")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatal("file is not set")
+	}
+
+	cfg := config.MustLoad()
+
+	source, err := filestorage.New(*path)
+	if err != nil {
+		log.Fatalf("failed to read graph: %s", err)
+	}
+
+	if _, err := distancegraph.New(source); err != nil {
+		log.Fatalf("invalid graph: %s", err)
+	}
+
+	vertices, err := source.GetVertices()
+	if err != nil {
+		log.Fatalf("failed to read vertices: %s", err)
+	}
+	paths, err := source.GetPaths()
+	if err != nil {
+		log.Fatalf("failed to read paths: %s", err)
+	}
+	restrictions, err := source.GetRestrictions()
+	if err != nil {
+		log.Fatalf("failed to read restrictions: %s", err)
+	}
+
+	gs, err := graphstorage.New(cfg.GS.Host, cfg.GS.Port, cfg.GS.User, cfg.GS.Password, cfg.GS.DBname)
+	if err != nil {
+		log.Fatalf("failed to connect to graph storage: %s", err)
+	}
+
+	if err := gs.ReplaceGraph(vertices, paths, restrictions); err != nil {
+		log.Fatalf("failed to import graph: %s", err)
+	}
+
+	log.Printf("imported %d vertices, %d edges and %d restrictions", len(vertices), len(paths), len(restrictions))
+}
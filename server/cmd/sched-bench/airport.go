@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+// fleet lists the bus models the synthetic fleet is drawn from.
+var fleet = []models.Bus{
+	{Type: "Cobus 3000", Capacity: 110},
+	{Type: "Cobus 3000", Capacity: 110},
+	{Type: "apron bus", Capacity: 50},
+	{Type: "PRM bus", Capacity: 20, Accessible: true},
+}
+
+type airportParams struct {
+	Stands   int
+	Gates    int
+	Parkings int
+	Flights  int
+	Buses    int
+	Interval time.Duration
+}
+
+type airport struct {
+	vertices []distancegraph.Vertex
+	paths    []distancegraph.Path
+	flights  []models.Flight
+	buses    []models.Bus
+}
+
+// generateAirport builds a random but reproducible airport: a connected apron
+// graph of stands, terminal gates and bus parkings, arrivals and departures
+// at the stands and a bus fleet.
+// Flight times are spread over the interval that starts at now.
+func generateAirport(rnd *rand.Rand, params airportParams, now time.Time) airport {
+	var a airport
+
+	stands := make([]string, params.Stands)
+	for i := range stands {
+		stands[i] = fmt.Sprintf("S%d", i+1)
+	}
+	gates := make([]string, params.Gates)
+	for i := range gates {
+		gates[i] = fmt.Sprintf("G%d", i+1)
+	}
+	parkings := make([]string, params.Parkings)
+	for i := range parkings {
+		parkings[i] = fmt.Sprintf("P%d", i+1)
+	}
+	for _, parking := range parkings {
+		a.vertices = append(a.vertices, distancegraph.Vertex{Name: parking, Type: distancegraph.VertexParking})
+	}
+	for _, gate := range gates {
+		a.vertices = append(a.vertices, distancegraph.Vertex{Name: gate, Type: distancegraph.VertexGate})
+	}
+	for _, stand := range stands {
+		a.vertices = append(a.vertices, distancegraph.Vertex{Name: stand, Type: distancegraph.VertexStand})
+	}
+
+	// A random spanning tree keeps the graph connected,
+	// extra edges add alternative routes.
+	connected := make(map[[2]int]bool)
+	connect := func(i, j int) {
+		if i == j || connected[[2]int{i, j}] {
+			return
+		}
+		connected[[2]int{i, j}] = true
+		connected[[2]int{j, i}] = true
+
+		length := 0.2 + rnd.Float64()*1.8
+		a.paths = append(a.paths,
+			distancegraph.Path{From: a.vertices[i].Name, To: a.vertices[j].Name, Length: length},
+			distancegraph.Path{From: a.vertices[j].Name, To: a.vertices[i].Name, Length: length},
+		)
+	}
+	for i := 1; i < len(a.vertices); i++ {
+		connect(i, rnd.Intn(i))
+	}
+	for k := 0; k < len(a.vertices)/2; k++ {
+		connect(rnd.Intn(len(a.vertices)), rnd.Intn(len(a.vertices)))
+	}
+
+	// Flights start a few minutes after now and end a minute before the
+	// interval so that the scheduler sees all of them.
+	window := params.Interval - 6*time.Minute
+	for i := 0; i < params.Flights; i++ {
+		direction := models.FlightArrival
+		if rnd.Intn(2) == 0 {
+			direction = models.FlightDeparture
+		}
+		a.flights = append(a.flights, models.Flight{
+			Id:            i + 1,
+			Direction:     direction,
+			Stand:         stands[rnd.Intn(len(stands))],
+			Gate:          gates[rnd.Intn(len(gates))],
+			ScheduledTime: now.Add(5*time.Minute + time.Duration(rnd.Int63n(int64(window)))),
+			Status:        "scheduled",
+			Passengers:    20 + rnd.Intn(280),
+		})
+	}
+
+	for i := 0; i < params.Buses; i++ {
+		bus := fleet[rnd.Intn(len(fleet))]
+		bus.Id = i + 1
+		bus.Status = models.BusStatusWork
+		bus.Parking = parkings[rnd.Intn(len(parkings))]
+		a.buses = append(a.buses, bus)
+	}
+
+	return a
+}
+
+func (a airport) GetVertices() ([]distancegraph.Vertex, error) {
+	return a.vertices, nil
+}
+
+func (a airport) GetPaths() ([]distancegraph.Path, error) {
+	return a.paths, nil
+}
+
+func (a airport) GetRestrictions() ([]distancegraph.Restriction, error) {
+	return nil, nil
+}
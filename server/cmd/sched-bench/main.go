@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/memory"
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/memory"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/scheduler"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/memory"
+)
+
+type result struct {
+	Strategy string `json:"strategy"`
+	scheduler.Metrics
+	Runtime time.Duration `json:"runtime_ns"`
+}
+
+func main() {
+	var (
+		seed       = flag.Int64("seed", 1, "seed of the synthetic airport")
+		strategies = flag.String("strategies", scheduler.StrategyGreedy+","+scheduler.StrategyMinCost, "comma separated list of strategies")
+		asJSON     = flag.Bool("json", false, "print results as JSON")
+		params     airportParams
+	)
+	flag.IntVar(&params.Stands, "stands", 40, "number of aircraft stands")
+	flag.IntVar(&params.Gates, "gates", 4, "number of terminal gates")
+	flag.IntVar(&params.Parkings, "parkings", 3, "number of bus parkings")
+	flag.IntVar(&params.Flights, "flights", 60, "number of flights")
+	flag.IntVar(&params.Buses, "buses", 20, "number of buses")
+	flag.DurationVar(&params.Interval, "interval", 3*time.Hour, "scheduling interval")
+	flag.Parse()
+
+	if params.Stands < 1 || params.Gates < 1 || params.Parkings < 1 || params.Interval <= 6*time.Minute {
+		log.Fatal("at least one stand, one gate, one parking and an interval longer than 6m are required")
+	}
+
+	rnd := rand.New(rand.NewSource(*seed))
+	airport := generateAirport(rnd, params, time.Now())
+
+	graph, err := distancegraph.NewHolder(airport)
+	if err != nil {
+		log.Fatalf("failed to build graph: %s", err)
+	}
+
+	var results []result
+	for _, name := range strings.Split(*strategies, ",") {
+		res, err := run(strings.TrimSpace(name), airport, graph, params.Interval)
+		if err != nil {
+			log.Fatalf("strategy %s: %s", name, err)
+		}
+		results = append(results, res)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "strategy\ttasks\tcoverage\tuncovered flights\tlate pickups\tempty km\truntime\t")
+	for _, res := range results {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%d\t%d\t%.2f\t%s\t\n",
+			res.Strategy, res.Tasks, res.Coverage*100, res.UncoveredFlights,
+			res.LatePickups, res.EmptyDistance, res.Runtime)
+	}
+	w.Flush()
+}
+
+func run(name string, a airport, graph *distancegraph.Holder, interval time.Duration) (result, error) {
+	strategy, err := scheduler.NewStrategy(name)
+	if err != nil {
+		return result{}, err
+	}
+
+	tasks := taskstorage.New()
+	storages := scheduler.Storages{
+		Flights: flightstorage.New(a.flights),
+		Buses:   busstorage.New(a.buses),
+		Tasks:   tasks,
+	}
+	sched := scheduler.NewWithStorages(storages, graph, strategy, scheduler.ServiceTimes{}, interval)
+
+	start := time.Now()
+	if err := sched.Create(); err != nil {
+		return result{}, err
+	}
+	runtime := time.Since(start)
+
+	created, err := tasks.GetTasks()
+	if err != nil {
+		return result{}, err
+	}
+
+	states := make([]scheduler.BusState, 0, len(a.buses))
+	for _, bus := range a.buses {
+		states = append(states, scheduler.BusState{Bus: bus, Location: bus.Parking, FreeAt: start})
+	}
+
+	return result{
+		Strategy: name,
+		Metrics:  scheduler.Evaluate(created, a.flights, states, graph.Graph()),
+		Runtime:  runtime,
+	}, nil
+}
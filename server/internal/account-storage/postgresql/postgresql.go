@@ -0,0 +1,74 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountExists   = errors.New("account already exists")
+)
+
+type AccountStorage struct {
+	db *sql.DB
+}
+
+func New(host, port, user, password, dbname string) (*AccountStorage, error) {
+	const op = "accountstorage.postgresql.New"
+
+	info := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, user, password, dbname)
+	db, err := sql.Open("postgres", info)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &AccountStorage{db: db}, nil
+}
+
+// AddAccount stores the account and returns it with its id.
+func (s *AccountStorage) AddAccount(account models.Account) (models.Account, error) {
+	const op = "accountstorage.postgresql.AddAccount"
+
+	stmt, err := s.db.Prepare("INSERT INTO accounts (login, role, password_hash) VALUES ($1, $2, $3) RETURNING id")
+	if err != nil {
+		return models.Account{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(account.Login, account.Role, account.PasswordHash).Scan(&account.Id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return models.Account{}, fmt.Errorf("%s: %w", op, ErrAccountExists)
+	}
+	if err != nil {
+		return models.Account{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return account, nil
+}
+
+func (s *AccountStorage) GetAccount(login string) (models.Account, error) {
+	const op = "accountstorage.postgresql.GetAccount"
+
+	stmt, err := s.db.Prepare("SELECT id, login, role, password_hash FROM accounts WHERE login = $1")
+	if err != nil {
+		return models.Account{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var account models.Account
+	err = stmt.QueryRow(login).Scan(&account.Id, &account.Login, &account.Role, &account.PasswordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Account{}, fmt.Errorf("%s: %w", op, ErrAccountNotFound)
+	}
+	if err != nil {
+		return models.Account{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return account, nil
+}
@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// BusStorage keeps buses in memory.
+type BusStorage struct {
+	mu    sync.RWMutex
+	buses []models.Bus
+}
+
+func New(buses []models.Bus) *BusStorage {
+	return &BusStorage{buses: buses}
+}
+
+func (s *BusStorage) GetBuses() ([]models.Bus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var buses []models.Bus
+	for _, bus := range s.buses {
+		if bus.Status == models.BusStatusWork {
+			buses = append(buses, bus)
+		}
+	}
+
+	return buses, nil
+}
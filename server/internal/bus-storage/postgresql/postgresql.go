@@ -2,7 +2,9 @@ package postgresql
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
 	_ "github.com/lib/pq"
@@ -27,7 +29,7 @@ func New(host, port, user, password, dbname string) (*BusStorage, error) {
 func (s *BusStorage) GetBuses() ([]models.Bus, error) {
 	const op = "busstorage.postgresql.GetBuses"
 
-	stmt, err := s.db.Prepare("SELECT * FROM buses WHERE status = 'in work'")
+	stmt, err := s.db.Prepare("SELECT id, status, parking, capacity, type, accessible FROM buses WHERE status = 'in work'")
 	if err != nil {
 		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
@@ -43,7 +45,7 @@ func (s *BusStorage) GetBuses() ([]models.Bus, error) {
 	for rows.Next() {
 		var bus models.Bus
 
-		err := rows.Scan(&bus.Id, &bus.Status, &bus.Parking)
+		err := rows.Scan(&bus.Id, &bus.Status, &bus.Parking, &bus.Capacity, &bus.Type, &bus.Accessible)
 		if err != nil {
 			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
 		}
@@ -52,3 +54,198 @@ func (s *BusStorage) GetBuses() ([]models.Bus, error) {
 
 	return buses, nil
 }
+
+// ListBuses returns the buses in any status.
+func (s *BusStorage) ListBuses() ([]models.Bus, error) {
+	const op = "busstorage.postgresql.ListBuses"
+
+	stmt, err := s.db.Prepare("SELECT id, status, parking, capacity, type, accessible FROM buses ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var buses []models.Bus
+	for rows.Next() {
+		var bus models.Bus
+
+		err := rows.Scan(&bus.Id, &bus.Status, &bus.Parking, &bus.Capacity, &bus.Type, &bus.Accessible)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		buses = append(buses, bus)
+	}
+
+	return buses, nil
+}
+
+var (
+	ErrBusNotFound = errors.New("bus not found")
+	ErrBusStatus   = errors.New("bus cannot change status")
+)
+
+func (s *BusStorage) GetBus(busID int) (models.Bus, error) {
+	const op = "busstorage.postgresql.GetBus"
+
+	stmt, err := s.db.Prepare("SELECT id, status, parking, capacity, type, accessible FROM buses WHERE id = $1")
+	if err != nil {
+		return models.Bus{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var bus models.Bus
+	err = stmt.QueryRow(busID).Scan(&bus.Id, &bus.Status, &bus.Parking, &bus.Capacity, &bus.Type, &bus.Accessible)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Bus{}, fmt.Errorf("%s: %w", op, ErrBusNotFound)
+	}
+	if err != nil {
+		return models.Bus{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return bus, nil
+}
+
+func (s *BusStorage) ChangeBusStatus(busID int, newStatus string) error {
+	const op = "busstorage.postgresql.ChangeBusStatus"
+
+	stmt, err := s.db.Prepare("UPDATE buses SET status = $1 WHERE id = $2")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(newStatus, busID)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// StartBreak moves the bus to the break status and records the break. A
+// bus that cannot go on break from its status is left as it is and
+// ErrBusStatus is returned.
+func (s *BusStorage) StartBreak(busID int, start time.Time, plannedEnd time.Time) (models.Break, error) {
+	const op = "busstorage.postgresql.StartBreak"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Break{}, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockBus(tx, busID)
+	if err != nil {
+		return models.Break{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !models.CanChangeBusStatus(status, models.BusStatusBreak) {
+		return models.Break{}, fmt.Errorf("%s: %w: from %q to %q", op, ErrBusStatus, status, models.BusStatusBreak)
+	}
+
+	_, err = tx.Exec("UPDATE buses SET status = $1 WHERE id = $2", models.BusStatusBreak, busID)
+	if err != nil {
+		return models.Break{}, fmt.Errorf("%s: update status: %w", op, err)
+	}
+
+	br := models.Break{BusID: busID, TimeStart: start, PlannedEnd: plannedEnd}
+	err = tx.QueryRow("INSERT INTO breaks (bus_id, time_start, planned_end) VALUES ($1, $2, $3) RETURNING id",
+		busID, start, plannedEnd).Scan(&br.Id)
+	if err != nil {
+		return models.Break{}, fmt.Errorf("%s: insert break: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Break{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return br, nil
+}
+
+// EndBreak returns the bus to work and closes its current break.
+func (s *BusStorage) EndBreak(busID int, end time.Time) error {
+	const op = "busstorage.postgresql.EndBreak"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE buses SET status = $1 WHERE id = $2", models.BusStatusWork, busID)
+	if err != nil {
+		return fmt.Errorf("%s: update status: %w", op, err)
+	}
+
+	_, err = tx.Exec("UPDATE breaks SET time_end = $1 WHERE bus_id = $2 AND time_end IS NULL", end, busID)
+	if err != nil {
+		return fmt.Errorf("%s: close break: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeOutOfService moves the bus out of service, closing its break if it
+// is on one, and returns the status it had. A bus already out of service is
+// left as it is, a bus that cannot go out of service from its status is
+// rejected with ErrBusStatus.
+func (s *BusStorage) TakeOutOfService(busID int, at time.Time) (string, error) {
+	const op = "busstorage.postgresql.TakeOutOfService"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockBus(tx, busID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if status == models.BusStatusOutOfService {
+		return status, nil
+	}
+	if !models.CanChangeBusStatus(status, models.BusStatusOutOfService) {
+		return status, fmt.Errorf("%s: %w: from %q to %q", op, ErrBusStatus, status, models.BusStatusOutOfService)
+	}
+
+	if status == models.BusStatusBreak {
+		_, err = tx.Exec("UPDATE breaks SET time_end = $1 WHERE bus_id = $2 AND time_end IS NULL", at, busID)
+		if err != nil {
+			return "", fmt.Errorf("%s: close break: %w", op, err)
+		}
+	}
+
+	_, err = tx.Exec("UPDATE buses SET status = $1 WHERE id = $2", models.BusStatusOutOfService, busID)
+	if err != nil {
+		return "", fmt.Errorf("%s: update status: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return status, nil
+}
+
+// lockBus selects the status of the bus for update in the transaction.
+func lockBus(tx *sql.Tx, busID int) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM buses WHERE id = $1 FOR UPDATE", busID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrBusNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("select bus: %w", err)
+	}
+	return status, nil
+}
@@ -1,25 +1,36 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"time"
 
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
 	Env        string `yaml:"env"`
 	HTTPServer `yaml:"http_server"`
-	FS         FlightStorage `yaml:"schedule_storage"`
-	BS         BusStorage    `yaml:"bus_storage"`
-	TS         TasksStorage  `yaml:"tasks_storage"`
+	FS         FlightStorage   `yaml:"schedule_storage"`
+	BS         BusStorage      `yaml:"bus_storage"`
+	TS         TasksStorage    `yaml:"tasks_storage"`
+	GS         GraphStorage    `yaml:"graph_storage"`
+	LS         LocationStorage `yaml:"location_storage"`
+	IS         IncidentStorage `yaml:"incident_storage"`
+	AS         AccountStorage  `yaml:"account_storage"`
+	DS         DriverStorage   `yaml:"driver_storage"`
+	AODB       AODB            `yaml:"aodb"`
+	Auth       Auth            `yaml:"auth"`
+	Scheduler  Scheduler       `yaml:"scheduler"`
 }
 
 type HTTPServer struct {
-	Address     string        `yaml:"address"`
-	Timeout     time.Duration `yaml:"timeout"`
-	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	Address        string        `yaml:"address"`
+	Timeout        time.Duration `yaml:"timeout"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout"`
+	AllowedOrigins []string      `yaml:"allowed_origins"` // browser origins allowed to open WebSockets
 }
 
 type FlightStorage struct {
@@ -46,6 +57,72 @@ type TasksStorage struct {
 	DBname   string `yaml:"dbname"`
 }
 
+type GraphStorage struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBname   string `yaml:"dbname"`
+}
+
+type LocationStorage struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBname   string `yaml:"dbname"`
+}
+
+type IncidentStorage struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBname   string `yaml:"dbname"`
+}
+
+type AccountStorage struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBname   string `yaml:"dbname"`
+}
+
+type DriverStorage struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBname   string `yaml:"dbname"`
+}
+
+// AODB is the feed of flight updates from the operational database of the airport.
+type AODB struct {
+	Token string `yaml:"token" env:"AODB_TOKEN"`
+}
+
+// Auth signs the session tokens of drivers, dispatchers and admins.
+type Auth struct {
+	Secret    string        `yaml:"secret" env:"AUTH_SECRET"`
+	TokenTTL  time.Duration `yaml:"token_ttl" env-default:"12h"`
+	TicketTTL time.Duration `yaml:"ticket_ttl" env-default:"30s"`
+}
+
+type Scheduler struct {
+	Strategy     string        `yaml:"strategy" env-default:"greedy"`
+	ServiceTimes []ServiceTime `yaml:"service_times"`
+}
+
+// ServiceTime is how long passengers board a bus and leave it. Empty
+// AircraftType or Direction match every aircraft type or direction.
+type ServiceTime struct {
+	AircraftType string        `yaml:"aircraft_type"`
+	Direction    string        `yaml:"direction"` // A - arrival, D - departure
+	Boarding     time.Duration `yaml:"boarding"`
+	Unloading    time.Duration `yaml:"unloading"`
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -62,5 +139,22 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %s", err)
 	}
 
+	if err := validate(&cfg); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
 	return &cfg
 }
+
+// validate checks the values cleanenv cannot check by itself.
+func validate(cfg *Config) error {
+	for i, entry := range cfg.Scheduler.ServiceTimes {
+		switch entry.Direction {
+		case "", models.FlightArrival, models.FlightDeparture:
+		default:
+			return fmt.Errorf("scheduler.service_times[%d]: direction %q is neither %q nor %q",
+				i, entry.Direction, models.FlightArrival, models.FlightDeparture)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestValidateServiceTimes(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction string
+		wantErr   bool
+	}{
+		{name: "any direction", direction: ""},
+		{name: "arrival", direction: "A"},
+		{name: "departure", direction: "D"},
+		{name: "lower case", direction: "a", wantErr: true},
+		{name: "word", direction: "arrival", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Scheduler: Scheduler{ServiceTimes: []ServiceTime{{Direction: tt.direction}}}}
+			if err := validate(&cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
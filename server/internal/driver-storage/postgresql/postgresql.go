@@ -0,0 +1,376 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDriverNotFound = errors.New("driver not found")
+	ErrShiftNotFound  = errors.New("shift not found")
+	ErrShiftOverlap   = errors.New("shift overlaps another shift of the driver or the bus")
+)
+
+const (
+	// checkInAdvance is how long before the start of the shift the driver may check in.
+	checkInAdvance = 30 * time.Minute
+	// checkOutGrace is how long after its end a shift the driver has checked
+	// in stays current without a check out.
+	checkOutGrace = 2 * time.Hour
+)
+
+// shiftColumns are the columns scanned by scanShift.
+const shiftColumns = "id, driver_id, bus_id, time_start, time_end, checked_in, checked_out"
+
+type DriverStorage struct {
+	db *sql.DB
+}
+
+func New(host, port, user, password, dbname string) (*DriverStorage, error) {
+	const op = "driverstorage.postgresql.New"
+
+	info := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, user, password, dbname)
+	db, err := sql.Open("postgres", info)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &DriverStorage{db: db}, nil
+}
+
+// AddDriver stores the driver and returns it with its id, the id the
+// driver signs in with.
+func (s *DriverStorage) AddDriver(driver models.Driver) (models.Driver, error) {
+	const op = "driverstorage.postgresql.AddDriver"
+
+	stmt, err := s.db.Prepare("INSERT INTO drivers (name, pin_hash) VALUES ($1, NULLIF($2, '')) RETURNING id")
+	if err != nil {
+		return models.Driver{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(driver.Name, driver.PinHash).Scan(&driver.Id)
+	if err != nil {
+		return models.Driver{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return driver, nil
+}
+
+func (s *DriverStorage) GetDriver(driverID int) (models.Driver, error) {
+	const op = "driverstorage.postgresql.GetDriver"
+
+	stmt, err := s.db.Prepare("SELECT id, name, COALESCE(pin_hash, '') FROM drivers WHERE id = $1")
+	if err != nil {
+		return models.Driver{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var driver models.Driver
+	err = stmt.QueryRow(driverID).Scan(&driver.Id, &driver.Name, &driver.PinHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Driver{}, fmt.Errorf("%s: %w", op, ErrDriverNotFound)
+	}
+	if err != nil {
+		return models.Driver{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return driver, nil
+}
+
+// SetPinHash replaces the PIN the driver signs in with.
+func (s *DriverStorage) SetPinHash(driverID int, pinHash string) error {
+	const op = "driverstorage.postgresql.SetPinHash"
+
+	stmt, err := s.db.Prepare("UPDATE drivers SET pin_hash = $2 WHERE id = $1")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(driverID, pinHash)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrDriverNotFound)
+	}
+
+	return nil
+}
+
+func (s *DriverStorage) ListDrivers() ([]models.Driver, error) {
+	const op = "driverstorage.postgresql.ListDrivers"
+
+	stmt, err := s.db.Prepare("SELECT id, name FROM drivers ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var drivers []models.Driver
+	for rows.Next() {
+		var driver models.Driver
+
+		err := rows.Scan(&driver.Id, &driver.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		drivers = append(drivers, driver)
+	}
+
+	return drivers, nil
+}
+
+// AddShift puts the shift on the roster and returns it with its id. The
+// shift may not overlap a shift of the same driver or bus, a shift the
+// driver has checked out of ends at the check out.
+func (s *DriverStorage) AddShift(shift models.Shift) (models.Shift, error) {
+	const op = "driverstorage.postgresql.AddShift"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Two dispatchers adding overlapping shifts at once must not both succeed.
+	_, err = tx.Exec("LOCK TABLE shifts IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: lock shifts: %w", op, err)
+	}
+
+	var overlap bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM shifts
+		WHERE (driver_id = $1 OR bus_id = $2) AND time_start < $4 AND COALESCE(checked_out, time_end) > $3)`,
+		shift.DriverID, shift.BusID, shift.TimeStart, shift.TimeEnd).Scan(&overlap)
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: check overlap: %w", op, err)
+	}
+	if overlap {
+		return models.Shift{}, fmt.Errorf("%s: %w", op, ErrShiftOverlap)
+	}
+
+	err = tx.QueryRow("INSERT INTO shifts (driver_id, bus_id, time_start, time_end) VALUES ($1, $2, $3, $4) RETURNING id",
+		shift.DriverID, shift.BusID, shift.TimeStart, shift.TimeEnd).Scan(&shift.Id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return models.Shift{}, fmt.Errorf("%s: %w", op, ErrDriverNotFound)
+	}
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: insert shift: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Shift{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return shift, nil
+}
+
+// GetShifts returns the shifts overlapping the window, those of one driver
+// if driverID is not zero.
+func (s *DriverStorage) GetShifts(driverID int, from time.Time, to time.Time) ([]models.Shift, error) {
+	const op = "driverstorage.postgresql.GetShifts"
+
+	stmt, err := s.db.Prepare("SELECT " + shiftColumns + ` FROM shifts
+		WHERE ($1 = 0 OR driver_id = $1) AND time_start < $3 AND time_end > $2 ORDER BY time_start, id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(driverID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var shifts []models.Shift
+	for rows.Next() {
+		shift, err := scanShift(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		shifts = append(shifts, shift)
+	}
+
+	return shifts, nil
+}
+
+func (s *DriverStorage) DeleteShift(shiftID int) error {
+	const op = "driverstorage.postgresql.DeleteShift"
+
+	stmt, err := s.db.Prepare("DELETE FROM shifts WHERE id = $1")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(shiftID)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: rows affected: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, ErrShiftNotFound)
+	}
+
+	return nil
+}
+
+// CurrentShift returns the shift the driver is on at the moment, see
+// currentShift.
+func (s *DriverStorage) CurrentShift(driverID int, at time.Time) (models.Shift, error) {
+	const op = "driverstorage.postgresql.CurrentShift"
+
+	stmt, err := s.db.Prepare("SELECT " + shiftColumns + `, EXISTS (SELECT 1 FROM shifts o
+			WHERE o.bus_id = s.bus_id AND o.id <> s.id AND o.time_start > s.time_start AND o.time_start <= $2)
+		FROM shifts s
+		WHERE driver_id = $1 AND checked_out IS NULL
+			AND ((checked_in IS NOT NULL AND time_end > $3) OR (time_start <= $2 AND time_end > $2))`)
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(driverID, at, at.Add(-checkOutGrace))
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var candidates []shiftCandidate
+	for rows.Next() {
+		var candidate shiftCandidate
+		err := rows.Scan(&candidate.Id, &candidate.DriverID, &candidate.BusID, &candidate.TimeStart, &candidate.TimeEnd,
+			&candidate.CheckedIn, &candidate.CheckedOut, &candidate.relieved)
+		if err != nil {
+			return models.Shift{}, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	shift, ok := currentShift(candidates, at)
+	if !ok {
+		return models.Shift{}, fmt.Errorf("%s: %w", op, ErrShiftNotFound)
+	}
+
+	return shift, nil
+}
+
+// shiftCandidate is a shift of the driver, relieved tells that another
+// shift of its bus has started after it.
+type shiftCandidate struct {
+	models.Shift
+	relieved bool
+}
+
+// currentShift picks the shift the driver is on at the moment: the one
+// whose window covers it, or else the latest one the driver has checked in
+// and not yet out of, up to checkOutGrace after its end. A shift the driver
+// has checked out of is over, and so is the grace once the next shift of the
+// bus has started, so that the bus has one driver at a time.
+func currentShift(candidates []shiftCandidate, at time.Time) (models.Shift, bool) {
+	var (
+		current models.Shift
+		found   bool
+	)
+	for _, candidate := range candidates {
+		shift := candidate.Shift
+		if shift.CheckedOut != nil {
+			continue
+		}
+		if !at.Before(shift.TimeStart) && at.Before(shift.TimeEnd) {
+			return shift, true
+		}
+		if shift.CheckedIn == nil || candidate.relieved || !shift.TimeEnd.After(at.Add(-checkOutGrace)) {
+			continue
+		}
+		if !found || shift.TimeStart.After(current.TimeStart) {
+			current, found = shift, true
+		}
+	}
+	return current, found
+}
+
+// CheckIn marks the arrival of the driver on the shift that covers the
+// moment or starts within checkInAdvance of it.
+func (s *DriverStorage) CheckIn(driverID int, at time.Time) (models.Shift, error) {
+	const op = "driverstorage.postgresql.CheckIn"
+
+	stmt, err := s.db.Prepare(`UPDATE shifts SET checked_in = $2 WHERE id = (
+		SELECT id FROM shifts
+		WHERE driver_id = $1 AND checked_in IS NULL AND checked_out IS NULL AND time_start <= $3 AND time_end > $2
+		ORDER BY time_start LIMIT 1) RETURNING ` + shiftColumns)
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	shift, err := scanShift(stmt.QueryRow(driverID, at, at.Add(checkInAdvance)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Shift{}, fmt.Errorf("%s: %w", op, ErrShiftNotFound)
+	}
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return shift, nil
+}
+
+// CheckOut ends the shift the driver has checked in.
+func (s *DriverStorage) CheckOut(driverID int, at time.Time) (models.Shift, error) {
+	const op = "driverstorage.postgresql.CheckOut"
+
+	stmt, err := s.db.Prepare(`UPDATE shifts SET checked_out = $2 WHERE id = (
+		SELECT id FROM shifts
+		WHERE driver_id = $1 AND checked_in IS NOT NULL AND checked_out IS NULL
+		ORDER BY time_start DESC LIMIT 1) RETURNING ` + shiftColumns)
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	shift, err := scanShift(stmt.QueryRow(driverID, at))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Shift{}, fmt.Errorf("%s: %w", op, ErrShiftNotFound)
+	}
+	if err != nil {
+		return models.Shift{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return shift, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanShift reads a shift selected with shiftColumns.
+func scanShift(row scanner) (models.Shift, error) {
+	var shift models.Shift
+	err := row.Scan(&shift.Id, &shift.DriverID, &shift.BusID, &shift.TimeStart, &shift.TimeEnd,
+		&shift.CheckedIn, &shift.CheckedOut)
+	return shift, err
+}
@@ -0,0 +1,84 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+func TestCurrentShift(t *testing.T) {
+	now := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := now.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	shift := func(id int, start, end int, checkedIn, checkedOut *time.Time) models.Shift {
+		return models.Shift{Id: id, DriverID: 1, BusID: 1, TimeStart: *at(start), TimeEnd: *at(end),
+			CheckedIn: checkedIn, CheckedOut: checkedOut}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []shiftCandidate
+		want       int
+	}{
+		{
+			name:       "window covers the moment",
+			candidates: []shiftCandidate{{Shift: shift(1, -60, 60, nil, nil)}},
+			want:       1,
+		},
+		{
+			name:       "checked in and in grace",
+			candidates: []shiftCandidate{{Shift: shift(1, -300, -60, at(-300), nil)}},
+			want:       1,
+		},
+		{
+			name:       "grace is over",
+			candidates: []shiftCandidate{{Shift: shift(1, -300, -150, at(-300), nil)}},
+		},
+		{
+			name:       "not checked in after the end",
+			candidates: []shiftCandidate{{Shift: shift(1, -300, -60, nil, nil)}},
+		},
+		{
+			name:       "checked out",
+			candidates: []shiftCandidate{{Shift: shift(1, -60, 60, at(-60), at(-10))}},
+		},
+		{
+			name:       "next shift of the bus has started",
+			candidates: []shiftCandidate{{Shift: shift(1, -300, -60, at(-300), nil), relieved: true}},
+		},
+		{
+			name: "covering shift wins over grace",
+			candidates: []shiftCandidate{
+				{Shift: shift(1, -300, -60, at(-300), nil)},
+				{Shift: shift(2, -60, 60, nil, nil)},
+			},
+			want: 2,
+		},
+		{
+			name: "latest shift in grace",
+			candidates: []shiftCandidate{
+				{Shift: shift(1, -300, -100, at(-300), nil)},
+				{Shift: shift(2, -90, -30, at(-90), nil)},
+			},
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := currentShift(tt.candidates, now)
+			if tt.want == 0 {
+				if ok {
+					t.Errorf("currentShift() = shift %d, want none", got.Id)
+				}
+				return
+			}
+			if !ok || got.Id != tt.want {
+				t.Errorf("currentShift() = shift %d, %v, want shift %d", got.Id, ok, tt.want)
+			}
+		})
+	}
+}
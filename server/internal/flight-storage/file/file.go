@@ -0,0 +1,342 @@
+package file
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// FlightStorage reads a seasonal flight schedule and expands it into daily
+// flights of one airport within a date range. Times are local times of the
+// airport, read in the time zone of the dates of the range.
+//
+// Files with the .csv extension have a header with the columns
+// "number,direction,from,to,days,time" and optional "aircraft_type,stand,gate,passengers":
+//
+//	number,direction,from,to,days,time,aircraft_type,stand,gate,passengers
+//	SU1234,A,2023-10-29,2024-03-30,1234567,14:35,A320,23,DGA_I,150
+//
+// where direction is A or D, from and to are the dates of the first and the
+// last flight and days lists the days of the week, Monday is 1.
+//
+// Other files are read as IATA SSIM (chapter 7). Only flight leg records
+// (type 3) departing from or arriving at the airport are used. The number of
+// passengers is the seat count of the aircraft configuration.
+type FlightStorage struct {
+	flights []models.Flight
+}
+
+// period is a flight repeated on some days of the week between two dates.
+type period struct {
+	number       string
+	direction    string
+	first        time.Time
+	last         time.Time
+	days         [7]bool // Monday first
+	hour         int
+	minute       int
+	dayShift     int // days between the date of the period and the flight
+	aircraftType string
+	stand        string
+	gate         string
+	passengers   int
+}
+
+func New(path string, airport string, from time.Time, to time.Time) (*FlightStorage, error) {
+	const op = "flightstorage.file.New"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	var periods []period
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		periods, err = readCSV(f)
+	} else {
+		periods, err = readSSIM(f, airport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &FlightStorage{flights: expand(periods, from, to)}, nil
+}
+
+// Flights returns the daily flights.
+func (s *FlightStorage) Flights() []models.Flight {
+	return s.flights
+}
+
+// expand turns the periods into flights on the dates from the range.
+func expand(periods []period, from time.Time, to time.Time) []models.Flight {
+	from = date(from, from.Location())
+	to = date(to, from.Location())
+
+	var flights []models.Flight
+	for _, p := range periods {
+		first, last := date(p.first, from.Location()), date(p.last, from.Location())
+		if first.Before(from) {
+			first = from
+		}
+		if last.After(to) || p.last.IsZero() {
+			last = to
+		}
+
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if !p.days[(int(day.Weekday())+6)%7] {
+				continue
+			}
+
+			flights = append(flights, models.Flight{
+				Number:        p.number,
+				Direction:     p.direction,
+				Date:          day.Format("2006-01-02"),
+				Stand:         p.stand,
+				Gate:          p.gate,
+				AircraftType:  p.aircraftType,
+				ScheduledTime: time.Date(day.Year(), day.Month(), day.Day()+p.dayShift, p.hour, p.minute, 0, 0, day.Location()),
+				Status:        models.FlightStatusScheduled,
+				Passengers:    p.passengers,
+			})
+		}
+	}
+
+	return flights
+}
+
+func date(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func readCSV(r io.Reader) ([]period, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"number", "direction", "from", "to", "days", "time"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("header has no column %q", name)
+		}
+	}
+
+	var periods []period
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		p := period{
+			number:       field("number"),
+			direction:    strings.ToUpper(field("direction")),
+			aircraftType: field("aircraft_type"),
+			stand:        field("stand"),
+			gate:         field("gate"),
+		}
+		if p.number == "" {
+			return nil, fmt.Errorf("line %d: number is empty", line)
+		}
+		if p.direction != models.FlightArrival && p.direction != models.FlightDeparture {
+			return nil, fmt.Errorf("line %d: direction must be A or D", line)
+		}
+
+		p.first, err = time.Parse("2006-01-02", field("from"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong from: %w", line, err)
+		}
+		p.last, err = time.Parse("2006-01-02", field("to"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong to: %w", line, err)
+		}
+
+		if err := parseDays(field("days"), &p.days); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		at, err := time.Parse("15:04", field("time"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong time: %w", line, err)
+		}
+		p.hour, p.minute = at.Hour(), at.Minute()
+
+		if value := field("passengers"); value != "" {
+			p.passengers, err = strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: wrong passengers: %w", line, err)
+			}
+		}
+
+		periods = append(periods, p)
+	}
+
+	return periods, nil
+}
+
+// SSIM flight leg record fields, 0-based half-open byte ranges.
+var (
+	ssimAirline      = [2]int{2, 5}
+	ssimFlightNumber = [2]int{5, 9}
+	ssimFrom         = [2]int{14, 21}
+	ssimTo           = [2]int{21, 28}
+	ssimDays         = [2]int{28, 35}
+	ssimDeparture    = [2]int{36, 39}
+	ssimSTD          = [2]int{39, 43}
+	ssimArrival      = [2]int{54, 57}
+	ssimSTA          = [2]int{61, 65}
+	ssimAircraftType = [2]int{72, 75}
+	ssimSeats        = [2]int{172, 192}
+	ssimDepShift     = 192
+	ssimArrShift     = 193
+)
+
+func readSSIM(r io.Reader, airport string) ([]period, error) {
+	if airport == "" {
+		return nil, errors.New("airport is required to read SSIM")
+	}
+
+	var periods []period
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		record := scanner.Text()
+		if len(record) == 0 || record[0] != '3' {
+			continue
+		}
+		if len(record) < 200 {
+			return nil, fmt.Errorf("line %d: flight leg record is shorter than 200 characters", line)
+		}
+
+		field := func(r [2]int) string {
+			return strings.TrimSpace(record[r[0]:r[1]])
+		}
+
+		var p period
+		var clock string
+		var shift byte
+		switch airport {
+		case field(ssimArrival):
+			p.direction, clock, shift = models.FlightArrival, field(ssimSTA), record[ssimArrShift]
+		case field(ssimDeparture):
+			p.direction, clock, shift = models.FlightDeparture, field(ssimSTD), record[ssimDepShift]
+		default:
+			continue
+		}
+
+		number, err := strconv.Atoi(field(ssimFlightNumber))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong flight number: %w", line, err)
+		}
+		p.number = field(ssimAirline) + strconv.Itoa(number)
+		p.aircraftType = field(ssimAircraftType)
+		p.passengers = seats(field(ssimSeats))
+
+		p.first, err = parseSSIMDate(field(ssimFrom))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong period start: %w", line, err)
+		}
+		p.last, err = parseSSIMDate(field(ssimTo))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong period end: %w", line, err)
+		}
+
+		if err := parseDays(strings.ReplaceAll(field(ssimDays), " ", ""), &p.days); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if len(clock) != 4 {
+			return nil, fmt.Errorf("line %d: wrong time %q", line, clock)
+		}
+		p.hour, err = strconv.Atoi(clock[:2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong time %q", line, clock)
+		}
+		p.minute, err = strconv.Atoi(clock[2:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: wrong time %q", line, clock)
+		}
+
+		// Date variation: digits are days after the period date, A is the day before.
+		switch {
+		case shift == 'A':
+			p.dayShift = -1
+		case shift >= '0' && shift <= '9':
+			p.dayShift = int(shift - '0')
+		}
+
+		periods = append(periods, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+// parseSSIMDate parses dates like 29OCT23. 00XXX00 is an open end and
+// gives the zero time.
+func parseSSIMDate(value string) (time.Time, error) {
+	if value == "00XXX00" {
+		return time.Time{}, nil
+	}
+	if len(value) != 7 {
+		return time.Time{}, fmt.Errorf("wrong date %q", value)
+	}
+	return time.Parse("02Jan06", value[:2]+value[2:3]+strings.ToLower(value[3:5])+value[5:])
+}
+
+// parseDays parses days of the week like "1234567" or "1 3 5".
+func parseDays(value string, days *[7]bool) error {
+	for _, c := range value {
+		if c == ' ' {
+			continue
+		}
+		if c < '1' || c > '7' {
+			return fmt.Errorf("wrong days of operation %q", value)
+		}
+		days[c-'1'] = true
+	}
+	return nil
+}
+
+// seats sums the seats of an aircraft configuration like "C12Y138".
+func seats(config string) int {
+	total, number := 0, 0
+	for _, c := range config {
+		if c >= '0' && c <= '9' {
+			number = number*10 + int(c-'0')
+			continue
+		}
+		total += number
+		number = 0
+	}
+	return total + number
+}
@@ -0,0 +1,152 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// ssimSchedule has the header, carrier and flight leg records of a schedule
+// for the airport SVX. SU1500 arrives the day after it departs, SU12 does not
+// fly to SVX.
+var ssimSchedule = []string{
+	"1AIRLINE STANDARD SCHEDULE DATA SET",
+	"2LSU  0008S23 29OCT2330MAR24",
+	"3 SU 12340101J29OCT2330MAR241234567 SVO08000800+0300  SVX12351235+0500  320                                                                                                 C12Y138             00000003",
+	"3 SU 12350101J29OCT2330MAR241 3 5   SVX13501350+0500  SVO14301430+0300  320                                                                                                 C12Y138             00000004",
+	"3 SU 15000101J29OCT2300XXX00     67 SVO23302330+0300  SVX04050405+0500  73H                                                                                                 Y189                01000005",
+	"3 SU   120101J29OCT2330MAR241234567 SVO09000900+0300  LED10201020+0300  321                                                                                                 Y170                00000006",
+	"5 SU                                                                                                                                                                                          000007E000007",
+}
+
+// flightKey is the part of a flight the tests compare.
+type flightKey struct {
+	number       string
+	direction    string
+	date         string
+	scheduled    string
+	aircraftType string
+	stand        string
+	gate         string
+	passengers   int
+}
+
+func keys(flights []models.Flight) []flightKey {
+	var result []flightKey
+	for _, f := range flights {
+		result = append(result, flightKey{f.Number, f.Direction, f.Date, f.ScheduledTime.Format("2006-01-02 15:04"),
+			f.AircraftType, f.Stand, f.Gate, f.Passengers})
+	}
+	return result
+}
+
+func writeFile(t *testing.T, name string, lines []string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestNew(t *testing.T) {
+	// Sunday to Tuesday.
+	from := time.Date(2023, 10, 29, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		file    string
+		lines   []string
+		airport string
+		want    []flightKey
+		wantErr bool
+	}{
+		{
+			name:    "ssim",
+			file:    "schedule.ssim",
+			lines:   ssimSchedule,
+			airport: "SVX",
+			want: []flightKey{
+				{"SU1234", "A", "2023-10-29", "2023-10-29 12:35", "320", "", "", 150},
+				{"SU1234", "A", "2023-10-30", "2023-10-30 12:35", "320", "", "", 150},
+				{"SU1234", "A", "2023-10-31", "2023-10-31 12:35", "320", "", "", 150},
+				{"SU1235", "D", "2023-10-30", "2023-10-30 13:50", "320", "", "", 150},
+				{"SU1500", "A", "2023-10-29", "2023-10-30 04:05", "73H", "", "", 189},
+			},
+		},
+		{
+			name:  "ssim without airport",
+			file:  "schedule.ssim",
+			lines: ssimSchedule,
+			// The airport is required to tell arrivals from departures.
+			wantErr: true,
+		},
+		{
+			name:    "ssim short record",
+			file:    "schedule.ssim",
+			lines:   []string{ssimSchedule[2][:120]},
+			airport: "SVX",
+			wantErr: true,
+		},
+		{
+			name: "csv",
+			file: "schedule.csv",
+			lines: []string{
+				"number,direction,from,to,days,time,aircraft_type,stand,gate,passengers",
+				"SU1234,A,2023-10-01,2023-10-30,1234567,14:35,A320,23,DGA_I,150",
+				"SU1235, d ,2023-10-01,2024-03-30,2,16:10,,,,",
+			},
+			want: []flightKey{
+				{"SU1234", "A", "2023-10-29", "2023-10-29 14:35", "A320", "23", "DGA_I", 150},
+				{"SU1234", "A", "2023-10-30", "2023-10-30 14:35", "A320", "23", "DGA_I", 150},
+				{"SU1235", "D", "2023-10-31", "2023-10-31 16:10", "", "", "", 0},
+			},
+		},
+		{
+			name: "csv without required column",
+			file: "schedule.csv",
+			lines: []string{
+				"number,direction,from,to,time",
+				"SU1234,A,2023-10-01,2023-10-30,14:35",
+			},
+			wantErr: true,
+		},
+		{
+			name: "csv wrong direction",
+			file: "schedule.csv",
+			lines: []string{
+				"number,direction,from,to,days,time",
+				"SU1234,X,2023-10-01,2023-10-30,1234567,14:35",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := New(writeFile(t, tt.file, tt.lines), tt.airport, from, to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got := keys(storage.Flights())
+			if len(got) != len(tt.want) {
+				t.Fatalf("Flights() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("flight %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// FlightStorage keeps flights in memory. It is used by the scheduler
+// benchmark and anywhere a database is not available. Changes made through
+// its methods are sent to the subscribers like the database triggers do.
+type FlightStorage struct {
+	mu          sync.RWMutex
+	flights     []models.Flight
+	fieldTimes  map[int]map[string]time.Time // by flight id, see ApplyFlightUpdate
+	subscribers []subscriber
+}
+
+type subscriber struct {
+	ctx     context.Context
+	changes chan models.FlightChange
+}
+
+func New(flights []models.Flight) *FlightStorage {
+	return &FlightStorage{flights: flights, fieldTimes: make(map[int]map[string]time.Time)}
+}
+
+func (s *FlightStorage) GetFlights(timeInterval time.Duration) ([]models.Flight, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	endTime := now.Add(timeInterval)
+
+	var flights []models.Flight
+	for _, flight := range s.flights {
+		if !flight.Time().Before(now) && !flight.Time().After(endTime) {
+			flights = append(flights, flight)
+		}
+	}
+
+	return flights, nil
+}
+
+func (s *FlightStorage) GetFlight(flightID int) (models.Flight, error) {
+	const op = "flightstorage.memory.GetFlight"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, flight := range s.flights {
+		if flight.Id == flightID {
+			return flight, nil
+		}
+	}
+	return models.Flight{}, fmt.Errorf("%s: %w", op, flightstorage.ErrFlightNotFound)
+}
+
+// AddFlight stores the flight, it keeps the id of the flight.
+func (s *FlightStorage) AddFlight(flight models.Flight) {
+	s.mu.Lock()
+	s.flights = append(s.flights, flight)
+	s.mu.Unlock()
+
+	s.notify(models.FlightChange{FlightID: flight.Id, Op: "INSERT"})
+}
+
+// UpdateFlight replaces the flight with the same id.
+func (s *FlightStorage) UpdateFlight(flight models.Flight) {
+	s.mu.Lock()
+	for i := range s.flights {
+		if s.flights[i].Id == flight.Id {
+			s.flights[i] = flight
+		}
+	}
+	s.mu.Unlock()
+
+	s.notify(models.FlightChange{FlightID: flight.Id, Op: "UPDATE"})
+}
+
+// ApplyFlightUpdate applies the update like the database storage does and
+// returns the same errors.
+func (s *FlightStorage) ApplyFlightUpdate(update models.FlightUpdate) (bool, error) {
+	const op = "flightstorage.memory.ApplyFlightUpdate"
+
+	if update.Status != nil && !models.IsFlightStatus(*update.Status) {
+		return false, fmt.Errorf("%s: %w: %q", op, flightstorage.ErrFlightStatus, *update.Status)
+	}
+
+	s.mu.Lock()
+	index := -1
+	for i, flight := range s.flights {
+		if flight.Number == update.Number && flight.Direction == update.Direction && flight.Date == update.Date {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		s.mu.Unlock()
+		return false, fmt.Errorf("%s: %w", op, flightstorage.ErrFlightNotFound)
+	}
+
+	flight := &s.flights[index]
+	if s.fieldTimes[flight.Id] == nil {
+		s.fieldTimes[flight.Id] = make(map[string]time.Time)
+	}
+	changed := update.Apply(flight, s.fieldTimes[flight.Id])
+	id := flight.Id
+	s.mu.Unlock()
+
+	if changed {
+		s.notify(models.FlightChange{FlightID: id, Op: "UPDATE"})
+	}
+	return changed, nil
+}
+
+func (s *FlightStorage) DeleteFlight(flightID int) {
+	s.mu.Lock()
+	flights := s.flights[:0]
+	for _, flight := range s.flights {
+		if flight.Id != flightID {
+			flights = append(flights, flight)
+		}
+	}
+	s.flights = flights
+	s.mu.Unlock()
+
+	s.notify(models.FlightChange{FlightID: flightID, Op: "DELETE"})
+}
+
+// Changes subscribes to the changes of the flights until the context is done.
+func (s *FlightStorage) Changes(ctx context.Context) (<-chan models.FlightChange, error) {
+	changes := make(chan models.FlightChange, 16)
+
+	s.mu.Lock()
+	s.subscribers = append(s.subscribers, subscriber{ctx: ctx, changes: changes})
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, sub := range s.subscribers {
+			if sub.changes == changes {
+				s.subscribers = append(s.subscribers[:i], s.subscribers[i+1:]...)
+				break
+			}
+		}
+		close(changes)
+	}()
+
+	return changes, nil
+}
+
+func (s *FlightStorage) notify(change models.FlightChange) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sub := range s.subscribers {
+		select {
+		case sub.changes <- change:
+		case <-sub.ctx.Done():
+		}
+	}
+}
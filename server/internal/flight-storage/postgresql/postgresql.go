@@ -1,16 +1,32 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// changesChannel is the channel the flights table triggers notify,
+// see migrations/009_flights_notify.sql.
+const changesChannel = "flight_changes"
+
+var (
+	ErrFlightNotFound = errors.New("flight not found")
+	ErrFlightStatus   = errors.New("unknown flight status")
+)
+
+// flightColumns are the columns scanned by scanFlight.
+const flightColumns = "id, number, direction, COALESCE(flight_date, scheduled_time::date), stand, gate, aircraft_type, scheduled_time, estimated_time, actual_time, status, passengers"
+
 type FlightStorage struct {
-	db *sql.DB
+	db   *sql.DB
+	info string
 }
 
 func New(host, port, user, password, dbname string) (*FlightStorage, error) {
@@ -22,22 +38,34 @@ func New(host, port, user, password, dbname string) (*FlightStorage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return &FlightStorage{db: db}, nil
+	return &FlightStorage{db: db, info: info}, nil
 }
 
 func (s *FlightStorage) GetFlights(timeInterval time.Duration) ([]models.Flight, error) {
 	const op = "flightstorage.postgresql.GetFlights"
 
 	now := time.Now()
-	endTime := now.Add(timeInterval)
+	flights, err := s.GetFlightsBetween(now, now.Add(timeInterval))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return flights, nil
+}
 
-	stmt, err := s.db.Prepare("SELECT * FROM flights WHERE time >= $1 AND time <= $2")
+// GetFlightsBetween returns the flights with the best known time in the window.
+func (s *FlightStorage) GetFlightsBetween(from time.Time, to time.Time) ([]models.Flight, error) {
+	const op = "flightstorage.postgresql.GetFlightsBetween"
+
+	stmt, err := s.db.Prepare("SELECT " + flightColumns + ` FROM flights
+		WHERE COALESCE(actual_time, estimated_time, scheduled_time) BETWEEN $1 AND $2
+		ORDER BY COALESCE(actual_time, estimated_time, scheduled_time)`)
 	if err != nil {
 		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(now.Format("2006-01-02 15:04:05"), endTime.Format("2006-01-02 15:04:05"))
+	rows, err := stmt.Query(from.Format("2006-01-02 15:04:05"), to.Format("2006-01-02 15:04:05"))
 	if err != nil {
 		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
 	}
@@ -45,9 +73,7 @@ func (s *FlightStorage) GetFlights(timeInterval time.Duration) ([]models.Flight,
 
 	var flights []models.Flight
 	for rows.Next() {
-		var flight models.Flight
-
-		err := rows.Scan(&flight.Id, &flight.Destination, &flight.Time, &flight.Status, &flight.Passengers)
+		flight, err := scanFlight(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
 		}
@@ -56,3 +82,182 @@ func (s *FlightStorage) GetFlights(timeInterval time.Duration) ([]models.Flight,
 
 	return flights, nil
 }
+
+func (s *FlightStorage) GetFlight(flightID int) (models.Flight, error) {
+	const op = "flightstorage.postgresql.GetFlight"
+
+	stmt, err := s.db.Prepare("SELECT " + flightColumns + " FROM flights WHERE id = $1")
+	if err != nil {
+		return models.Flight{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	flight, err := scanFlight(stmt.QueryRow(flightID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Flight{}, fmt.Errorf("%s: %w", op, ErrFlightNotFound)
+	}
+	if err != nil {
+		return models.Flight{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return flight, nil
+}
+
+// UpsertFlights adds the flights or updates those with the same number,
+// direction and date, see migrations/019_flights_date.sql. The scheduled
+// time may move to another day without duplicating the flight. Stand, gate
+// and passengers are only overwritten when known, so that values set by the
+// dispatcher survive a repeated import. It returns the number of added flights.
+func (s *FlightStorage) UpsertFlights(flights []models.Flight) (int, error) {
+	const op = "flightstorage.postgresql.UpsertFlights"
+
+	stmt, err := s.db.Prepare(`INSERT INTO flights (number, direction, flight_date, stand, gate, aircraft_type, scheduled_time, status, passengers)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (number, direction, flight_date) WHERE number != '' DO UPDATE SET
+			scheduled_time = EXCLUDED.scheduled_time,
+			aircraft_type = EXCLUDED.aircraft_type,
+			stand = CASE WHEN EXCLUDED.stand != '' THEN EXCLUDED.stand ELSE flights.stand END,
+			gate = CASE WHEN EXCLUDED.gate != '' THEN EXCLUDED.gate ELSE flights.gate END,
+			passengers = CASE WHEN EXCLUDED.passengers > 0 THEN EXCLUDED.passengers ELSE flights.passengers END
+		RETURNING (xmax = 0)`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	added := 0
+	for _, flight := range flights {
+		var inserted bool
+		err := stmt.QueryRow(flight.Number, flight.Direction, flight.Date, flight.Stand, flight.Gate, flight.AircraftType,
+			flight.ScheduledTime.Format("2006-01-02 15:04:05"), flight.Status, flight.Passengers).Scan(&inserted)
+		if err != nil {
+			return added, fmt.Errorf("%s: execute statement: %w", op, err)
+		}
+		if inserted {
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// ApplyFlightUpdate writes the fields of the update that are newer than the
+// last change of the same field, see models.FlightUpdate.Apply. It reports
+// whether the flight changed, the flights_updated trigger then notifies the
+// listeners. A status other than the flight statuses gives ErrFlightStatus.
+func (s *FlightStorage) ApplyFlightUpdate(update models.FlightUpdate) (bool, error) {
+	const op = "flightstorage.postgresql.ApplyFlightUpdate"
+
+	if update.Status != nil && !models.IsFlightStatus(*update.Status) {
+		return false, fmt.Errorf("%s: %w: %q", op, ErrFlightStatus, *update.Status)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var flight models.Flight
+	var times []byte
+	err = tx.QueryRow(`SELECT id, estimated_time, actual_time, stand, gate, status, passengers, field_times
+		FROM flights WHERE number = $1 AND direction = $2 AND COALESCE(flight_date, scheduled_time::date) = $3 FOR UPDATE`,
+		update.Number, update.Direction, update.Date).Scan(&flight.Id, &flight.EstimatedTime, &flight.ActualTime,
+		&flight.Stand, &flight.Gate, &flight.Status, &flight.Passengers, &times)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("%s: %w", op, ErrFlightNotFound)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: select flight: %w", op, err)
+	}
+
+	fieldTimes := make(map[string]time.Time)
+	if err := json.Unmarshal(times, &fieldTimes); err != nil {
+		return false, fmt.Errorf("%s: unmarshal field times: %w", op, err)
+	}
+
+	if !update.Apply(&flight, fieldTimes) {
+		return false, nil
+	}
+
+	times, err = json.Marshal(fieldTimes)
+	if err != nil {
+		return false, fmt.Errorf("%s: marshal field times: %w", op, err)
+	}
+
+	_, err = tx.Exec(`UPDATE flights SET estimated_time = $1, actual_time = $2, stand = $3, gate = $4, status = $5,
+		passengers = $6, field_times = $7 WHERE id = $8`, flight.EstimatedTime, flight.ActualTime, flight.Stand,
+		flight.Gate, flight.Status, flight.Passengers, times, flight.Id)
+	if err != nil {
+		return false, fmt.Errorf("%s: update flight: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// Changes listens for changes of the flights table until the context is done.
+// Notifications sent while the connection is being restored are lost, the
+// periodic planning cycle catches up with them.
+func (s *FlightStorage) Changes(ctx context.Context) (<-chan models.FlightChange, error) {
+	const op = "flightstorage.postgresql.Changes"
+
+	listener := pq.NewListener(s.info, 10*time.Second, time.Minute, nil)
+	err := listener.Listen(changesChannel)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("%s: listen: %w", op, err)
+	}
+
+	changes := make(chan models.FlightChange)
+	go func() {
+		defer close(changes)
+		defer listener.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				// nil is sent after the connection was restored.
+				if n == nil {
+					continue
+				}
+
+				var change models.FlightChange
+				if err := json.Unmarshal([]byte(n.Extra), &change); err != nil {
+					continue
+				}
+
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			case <-time.After(90 * time.Second):
+				go listener.Ping()
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFlight reads a flight selected with flightColumns.
+func scanFlight(row scanner) (models.Flight, error) {
+	var flight models.Flight
+	var date time.Time
+
+	err := row.Scan(&flight.Id, &flight.Number, &flight.Direction, &date, &flight.Stand, &flight.Gate, &flight.AircraftType,
+		&flight.ScheduledTime, &flight.EstimatedTime, &flight.ActualTime, &flight.Status, &flight.Passengers)
+	flight.Date = date.Format("2006-01-02")
+
+	return flight, err
+}
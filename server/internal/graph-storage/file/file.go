@@ -0,0 +1,211 @@
+package file
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"gopkg.in/yaml.v3"
+)
+
+// GraphStorage reads the airport graph from a YAML or CSV file.
+//
+// YAML format:
+//
+//	vertices:
+//	  - "DGA_D"
+//	  - {name: "P1", type: "parking", lat: 55.9726, lon: 37.4146}
+//	  - {name: "23", type: "stand", lat: 55.9751, lon: 37.4098}
+//	edges:
+//	  - {from: "P1", to: "23", length: 0.8, bidirectional: true}
+//	  - {from: "P1", to: "DGA_D", bidirectional: true} # length from coordinates
+//	restrictions:
+//	  - {from: "P1", to: "23", start: "2023-08-24T14:00:00+03:00", end: "2023-08-24T16:00:00+03:00", max_speed: 0}
+//
+// CSV format has the header "from,to,length[,bidirectional]",
+// vertices are taken from the edges.
+type GraphStorage struct {
+	vertices     []distancegraph.Vertex
+	paths        []distancegraph.Path
+	restrictions []distancegraph.Restriction
+}
+
+type edge struct {
+	From          string  `yaml:"from"`
+	To            string  `yaml:"to"`
+	Length        float64 `yaml:"length"`
+	Bidirectional bool    `yaml:"bidirectional"`
+}
+
+type vertex struct {
+	Name string  `yaml:"name"`
+	Type string  `yaml:"type"`
+	Lat  float64 `yaml:"lat"`
+	Lon  float64 `yaml:"lon"`
+}
+
+// UnmarshalYAML allows to write a vertex without attributes as a plain name.
+func (v *vertex) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		v.Name = node.Value
+		return nil
+	}
+
+	type plain vertex
+	return node.Decode((*plain)(v))
+}
+
+type restriction struct {
+	From     string  `yaml:"from"`
+	To       string  `yaml:"to"`
+	Start    string  `yaml:"start"`
+	End      string  `yaml:"end"`
+	MaxSpeed float64 `yaml:"max_speed"`
+}
+
+type graphFile struct {
+	Vertices     []vertex      `yaml:"vertices"`
+	Edges        []edge        `yaml:"edges"`
+	Restrictions []restriction `yaml:"restrictions"`
+}
+
+func New(path string) (*GraphStorage, error) {
+	const op = "graphstorage.file.New"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	var graph graphFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.NewDecoder(f).Decode(&graph)
+	case ".csv":
+		graph, err = readCSV(f)
+	default:
+		err = fmt.Errorf("unknown file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	storage, err := newStorage(graph)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return storage, nil
+}
+
+func (s *GraphStorage) GetVertices() ([]distancegraph.Vertex, error) {
+	return s.vertices, nil
+}
+
+func (s *GraphStorage) GetPaths() ([]distancegraph.Path, error) {
+	return s.paths, nil
+}
+
+func (s *GraphStorage) GetRestrictions() ([]distancegraph.Restriction, error) {
+	return s.restrictions, nil
+}
+
+func newStorage(graph graphFile) (*GraphStorage, error) {
+	s := &GraphStorage{}
+
+	known := make(map[string]bool)
+	addVertex := func(v vertex) {
+		if !known[v.Name] {
+			known[v.Name] = true
+			s.vertices = append(s.vertices, distancegraph.Vertex{Name: v.Name, Type: v.Type, Lat: v.Lat, Lon: v.Lon})
+		}
+	}
+
+	for _, v := range graph.Vertices {
+		addVertex(v)
+	}
+	for _, e := range graph.Edges {
+		addVertex(vertex{Name: e.From})
+		addVertex(vertex{Name: e.To})
+
+		s.paths = append(s.paths, distancegraph.Path{From: e.From, To: e.To, Length: e.Length})
+		if e.Bidirectional {
+			s.paths = append(s.paths, distancegraph.Path{From: e.To, To: e.From, Length: e.Length})
+		}
+	}
+
+	for _, r := range graph.Restrictions {
+		start, err := time.Parse(time.RFC3339, r.Start)
+		if err != nil {
+			return nil, fmt.Errorf("restriction %s-%s: wrong start: %w", r.From, r.To, err)
+		}
+		end, err := time.Parse(time.RFC3339, r.End)
+		if err != nil {
+			return nil, fmt.Errorf("restriction %s-%s: wrong end: %w", r.From, r.To, err)
+		}
+
+		s.restrictions = append(s.restrictions, distancegraph.Restriction{
+			From:     r.From,
+			To:       r.To,
+			Start:    start,
+			End:      end,
+			MaxSpeed: r.MaxSpeed,
+		})
+	}
+
+	return s, nil
+}
+
+func readCSV(r io.Reader) (graphFile, error) {
+	var graph graphFile
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return graph, fmt.Errorf("read header: %w", err)
+	}
+	if len(header) < 3 {
+		return graph, errors.New("header must be from,to,length[,bidirectional]")
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return graph, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(record) < 3 {
+			return graph, fmt.Errorf("line %d: expected at least 3 fields", line)
+		}
+
+		e := edge{From: record[0], To: record[1]}
+		if record[2] != "" {
+			e.Length, err = strconv.ParseFloat(record[2], 64)
+			if err != nil {
+				return graph, fmt.Errorf("line %d: wrong length: %w", line, err)
+			}
+		}
+		if len(record) > 3 && record[3] != "" {
+			e.Bidirectional, err = strconv.ParseBool(record[3])
+			if err != nil {
+				return graph, fmt.Errorf("line %d: wrong bidirectional flag: %w", line, err)
+			}
+		}
+		graph.Edges = append(graph.Edges, e)
+	}
+
+	return graph, nil
+}
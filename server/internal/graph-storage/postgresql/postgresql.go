@@ -0,0 +1,198 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	_ "github.com/lib/pq"
+)
+
+type GraphStorage struct {
+	db *sql.DB
+}
+
+func New(host, port, user, password, dbname string) (*GraphStorage, error) {
+	const op = "graphstorage.postgresql.New"
+
+	info := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, user, password, dbname)
+	db, err := sql.Open("postgres", info)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &GraphStorage{db: db}, nil
+}
+
+func (s *GraphStorage) GetVertices() ([]distancegraph.Vertex, error) {
+	const op = "graphstorage.postgresql.GetVertices"
+
+	stmt, err := s.db.Prepare("SELECT name, type, lat, lon FROM graph_vertices")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var vertices []distancegraph.Vertex
+	for rows.Next() {
+		var vertex distancegraph.Vertex
+
+		err := rows.Scan(&vertex.Name, &vertex.Type, &vertex.Lat, &vertex.Lon)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		vertices = append(vertices, vertex)
+	}
+
+	return vertices, nil
+}
+
+func (s *GraphStorage) GetPaths() ([]distancegraph.Path, error) {
+	const op = "graphstorage.postgresql.GetPaths"
+
+	stmt, err := s.db.Prepare("SELECT from_vertex, to_vertex, length FROM graph_edges")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var paths []distancegraph.Path
+	for rows.Next() {
+		var path distancegraph.Path
+		var length sql.NullFloat64
+
+		err := rows.Scan(&path.From, &path.To, &length)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		path.Length = length.Float64
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// GetRestrictions returns restrictions that have not ended yet.
+func (s *GraphStorage) GetRestrictions() ([]distancegraph.Restriction, error) {
+	const op = "graphstorage.postgresql.GetRestrictions"
+
+	stmt, err := s.db.Prepare("SELECT from_vertex, to_vertex, time_start, time_end, max_speed FROM graph_restrictions WHERE time_end > $1")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var restrictions []distancegraph.Restriction
+	for rows.Next() {
+		var r distancegraph.Restriction
+
+		err := rows.Scan(&r.From, &r.To, &r.Start, &r.End, &r.MaxSpeed)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		restrictions = append(restrictions, r)
+	}
+
+	return restrictions, nil
+}
+
+func (s *GraphStorage) AddRestriction(r distancegraph.Restriction) error {
+	const op = "graphstorage.postgresql.AddRestriction"
+
+	stmt, err := s.db.Prepare("INSERT INTO graph_restrictions (from_vertex, to_vertex, time_start, time_end, max_speed) VALUES ($1, $2, $3, $4, $5)")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(r.From, r.To, r.Start, r.End, r.MaxSpeed)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReplaceGraph atomically replaces all vertices, edges and restrictions of the graph.
+func (s *GraphStorage) ReplaceGraph(vertices []distancegraph.Vertex, paths []distancegraph.Path, restrictions []distancegraph.Restriction) error {
+	const op = "graphstorage.postgresql.ReplaceGraph"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM graph_restrictions"); err != nil {
+		return fmt.Errorf("%s: delete restrictions: %w", op, err)
+	}
+	if _, err := tx.Exec("DELETE FROM graph_edges"); err != nil {
+		return fmt.Errorf("%s: delete edges: %w", op, err)
+	}
+	if _, err := tx.Exec("DELETE FROM graph_vertices"); err != nil {
+		return fmt.Errorf("%s: delete vertices: %w", op, err)
+	}
+
+	vertexStmt, err := tx.Prepare("INSERT INTO graph_vertices (name, type, lat, lon) VALUES ($1, $2, $3, $4)")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer vertexStmt.Close()
+
+	for _, vertex := range vertices {
+		if _, err := vertexStmt.Exec(vertex.Name, vertex.Type, vertex.Lat, vertex.Lon); err != nil {
+			return fmt.Errorf("%s: execute statement: %w", op, err)
+		}
+	}
+
+	edgeStmt, err := tx.Prepare("INSERT INTO graph_edges (from_vertex, to_vertex, length) VALUES ($1, $2, $3)")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer edgeStmt.Close()
+
+	for _, path := range paths {
+		// A missing length is NULL, it is computed from the coordinates on load.
+		length := sql.NullFloat64{Float64: path.Length, Valid: path.Length != 0}
+		if _, err := edgeStmt.Exec(path.From, path.To, length); err != nil {
+			return fmt.Errorf("%s: execute statement: %w", op, err)
+		}
+	}
+
+	restrictionStmt, err := tx.Prepare("INSERT INTO graph_restrictions (from_vertex, to_vertex, time_start, time_end, max_speed) VALUES ($1, $2, $3, $4, $5)")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer restrictionStmt.Close()
+
+	for _, r := range restrictions {
+		if _, err := restrictionStmt.Exec(r.From, r.To, r.Start, r.End, r.MaxSpeed); err != nil {
+			return fmt.Errorf("%s: execute statement: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return nil
+}
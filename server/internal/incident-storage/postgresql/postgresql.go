@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	_ "github.com/lib/pq"
+)
+
+var ErrIncidentNotFound = errors.New("incident not found")
+
+type IncidentStorage struct {
+	db *sql.DB
+}
+
+func New(host, port, user, password, dbname string) (*IncidentStorage, error) {
+	const op = "incidentstorage.postgresql.New"
+
+	info := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, user, password, dbname)
+	db, err := sql.Open("postgres", info)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &IncidentStorage{db: db}, nil
+}
+
+func (s *IncidentStorage) AddIncident(incident models.Incident) (int, error) {
+	const op = "incidentstorage.postgresql.AddIncident"
+
+	stmt, err := s.db.Prepare(`INSERT INTO incidents (bus_id, task_id, type, description, status, time_created)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var id int
+	err = stmt.QueryRow(incident.BusID, incident.TaskID, incident.Type, incident.Description,
+		incident.Status, incident.TimeCreated).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetIncidents returns incidents with the given status, or all incidents
+// if the status is empty, newest first.
+func (s *IncidentStorage) GetIncidents(status string) ([]models.Incident, error) {
+	const op = "incidentstorage.postgresql.GetIncidents"
+
+	stmt, err := s.db.Prepare(`SELECT id, bus_id, task_id, type, description, status, time_created, time_resolved, resolution
+		FROM incidents WHERE $1 = '' OR status = $1 ORDER BY time_created DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(status)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var incidents []models.Incident
+	for rows.Next() {
+		var incident models.Incident
+
+		err := rows.Scan(&incident.Id, &incident.BusID, &incident.TaskID, &incident.Type, &incident.Description,
+			&incident.Status, &incident.TimeCreated, &incident.TimeResolved, &incident.Resolution)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		incidents = append(incidents, incident)
+	}
+
+	return incidents, nil
+}
+
+// ResolveIncident closes an open incident and returns it.
+func (s *IncidentStorage) ResolveIncident(incidentID int, resolution string, at time.Time) (models.Incident, error) {
+	const op = "incidentstorage.postgresql.ResolveIncident"
+
+	stmt, err := s.db.Prepare(`UPDATE incidents SET status = $1, resolution = $2, time_resolved = $3
+		WHERE id = $4 AND status = $5
+		RETURNING id, bus_id, task_id, type, description, status, time_created, time_resolved, resolution`)
+	if err != nil {
+		return models.Incident{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var incident models.Incident
+	err = stmt.QueryRow(models.IncidentStatusResolved, resolution, at, incidentID, models.IncidentStatusOpen).Scan(
+		&incident.Id, &incident.BusID, &incident.TaskID, &incident.Type, &incident.Description,
+		&incident.Status, &incident.TimeCreated, &incident.TimeResolved, &incident.Resolution)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Incident{}, fmt.Errorf("%s: %w", op, ErrIncidentNotFound)
+	}
+	if err != nil {
+		return models.Incident{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return incident, nil
+}
+
+// HasOpenIncidents reports whether the bus has incidents that are not resolved.
+func (s *IncidentStorage) HasOpenIncidents(busID int) (bool, error) {
+	const op = "incidentstorage.postgresql.HasOpenIncidents"
+
+	stmt, err := s.db.Prepare("SELECT EXISTS (SELECT 1 FROM incidents WHERE bus_id = $1 AND status = $2)")
+	if err != nil {
+		return false, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var exists bool
+	err = stmt.QueryRow(busID, models.IncidentStatusOpen).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,58 @@
+package aodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// Batch is the body of a webhook request.
+type Batch struct {
+	Updates []models.FlightUpdate `json:"updates"`
+}
+
+// Client plays the operational database of the airport: it sends batches of
+// flight updates to the webhook of the server.
+type Client struct {
+	URL    string
+	Token  string
+	Client *http.Client // http.DefaultClient if nil
+}
+
+// Send posts the batch and returns the status code and the body of the answer.
+func (c Client) Send(batch Batch) (int, []byte, error) {
+	const op = "lib.aodb.Send"
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: encode batch: %w", op, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: create request: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: send batch: %w", op, err)
+	}
+	defer res.Body.Close()
+
+	answer, err := io.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, nil, fmt.Errorf("%s: read answer: %w", op, err)
+	}
+
+	return res.StatusCode, answer, nil
+}
@@ -0,0 +1,39 @@
+package etag
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrWrongFormat = errors.New("wrong If-Match format")
+
+// Format returns the entity tag of the version of a task.
+func Format(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Version returns the version of a task in the If-Match header. Zero means
+// any version: the header is missing or "*". Only one strong tag made by
+// Format is accepted.
+func Version(ifMatch string) (int, error) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	value, ok := strings.CutPrefix(ifMatch, `"`)
+	if !ok {
+		return 0, ErrWrongFormat
+	}
+	value, ok = strings.CutSuffix(value, `"`)
+	if !ok {
+		return 0, ErrWrongFormat
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, ErrWrongFormat
+	}
+	return version, nil
+}
@@ -0,0 +1,41 @@
+package etag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		ifMatch string
+		want    int
+		wantErr bool
+	}{
+		{ifMatch: "", want: 0},
+		{ifMatch: "*", want: 0},
+		{ifMatch: ` "3" `, want: 3},
+		{ifMatch: Format(12), want: 12},
+		{ifMatch: "3", wantErr: true},
+		{ifMatch: `W/"3"`, wantErr: true},
+		{ifMatch: `"3`, wantErr: true},
+		{ifMatch: `3"`, wantErr: true},
+		{ifMatch: `""`, wantErr: true},
+		{ifMatch: `"0"`, wantErr: true},
+		{ifMatch: `"-1"`, wantErr: true},
+		{ifMatch: `"abc"`, wantErr: true},
+		{ifMatch: `"3", "4"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Version(tt.ifMatch)
+		if tt.wantErr {
+			if !errors.Is(err, ErrWrongFormat) {
+				t.Errorf("Version(%q) error = %v, want %v", tt.ifMatch, err, ErrWrongFormat)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Version(%q) = %d, %v, want %d", tt.ifMatch, got, err, tt.want)
+		}
+	}
+}
@@ -6,8 +6,9 @@ type Response struct {
 }
 
 const (
-	StatusOK    = "OK"
-	StatusError = "Error"
+	StatusOK      = "OK"
+	StatusError   = "Error"
+	StatusPartial = "Partial" // the request was done, a follow-up step failed
 )
 
 func OK() Response {
@@ -22,3 +23,10 @@ func Error(msg string) Response {
 		Error:  msg,
 	}
 }
+
+func Partial(msg string) Response {
+	return Response{
+		Status: StatusPartial,
+		Error:  msg,
+	}
+}
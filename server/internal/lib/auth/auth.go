@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrInvalidTicket = errors.New("invalid ticket")
+)
+
+// DummyHash is a bcrypt hash of the default cost to check the password of an
+// unknown user against, so that the answer takes as long as for a known one.
+const DummyHash = "$2a$10$aA5.v6TtFejXTlIcSxAkyuDA25Ns.TAAGGXYJDdD.aTmrWKE/4OTy"
+
+// User is who sent the request. BusID is the bus of the current shift of a
+// driver: it is not in the token, access.Shift finds it on every request.
+type User struct {
+	ID    int
+	Role  string
+	BusID int
+}
+
+type claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Tokens issues and checks session tokens signed with HMAC-SHA256.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokens(secret string, ttl time.Duration) (*Tokens, error) {
+	const op = "lib.auth.NewTokens"
+
+	if secret == "" {
+		return nil, fmt.Errorf("%s: secret is empty", op)
+	}
+	return &Tokens{secret: []byte(secret), ttl: ttl}, nil
+}
+
+// Issue returns a token of the user valid for the lifetime of tokens.
+func (t *Tokens) Issue(user User) (string, time.Time, error) {
+	const op = "lib.auth.Issue"
+
+	now := time.Now()
+	expires := now.Add(t.ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	})
+
+	signed, err := token.SignedString(t.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return signed, expires, nil
+}
+
+// Parse checks the signature and the expiry of the token and returns its user.
+func (t *Tokens) Parse(token string) (User, error) {
+	const op = "lib.auth.Parse"
+
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return t.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return User{}, fmt.Errorf("%s: %w: %s", op, ErrInvalidToken, err)
+	}
+
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return User{}, fmt.Errorf("%s: %w: wrong subject", op, ErrInvalidToken)
+	}
+
+	return User{ID: id, Role: c.Role}, nil
+}
+
+// Tickets issues single-use tickets that open an event stream. Browsers
+// cannot send headers with EventSource and WebSocket, so the ticket goes in
+// the URL instead of the session token and is worthless once used or
+// expired.
+type Tickets struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]ticket
+}
+
+type ticket struct {
+	user    User
+	expires time.Time
+}
+
+func NewTickets(ttl time.Duration) *Tickets {
+	return &Tickets{ttl: ttl, tickets: make(map[string]ticket)}
+}
+
+// Issue returns a new ticket of the user and its expiry.
+func (t *Tickets) Issue(user User) (string, time.Time, error) {
+	const op = "lib.auth.Tickets.Issue"
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	value := hex.EncodeToString(b)
+
+	now := time.Now()
+	expires := now.Add(t.ttl)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for value, ticket := range t.tickets {
+		if !ticket.expires.After(now) {
+			delete(t.tickets, value)
+		}
+	}
+	t.tickets[value] = ticket{user: User{ID: user.ID, Role: user.Role}, expires: expires}
+
+	return value, expires, nil
+}
+
+// Redeem returns the user of the ticket and makes the ticket invalid.
+func (t *Tickets) Redeem(value string) (User, error) {
+	const op = "lib.auth.Tickets.Redeem"
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ticket, ok := t.tickets[value]
+	delete(t.tickets, value)
+	if !ok || !ticket.expires.After(time.Now()) {
+		return User{}, fmt.Errorf("%s: %w", op, ErrInvalidTicket)
+	}
+	return ticket.user, nil
+}
+
+// pinDigits is the length of the PINs of the drivers.
+const pinDigits = 8
+
+// NewPIN returns a random PIN for a driver to sign in with.
+func NewPIN() (string, error) {
+	const op = "lib.auth.NewPIN"
+
+	b := make([]byte, pinDigits)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	// 256 is a multiple of 2, not of 10, so a byte modulo 10 would favour
+	// the low digits a little; the bytes from 250 on are drawn again.
+	for i := range b {
+		for b[i] >= 250 {
+			if _, err := rand.Read(b[i : i+1]); err != nil {
+				return "", fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		b[i] = '0' + b[i]%10
+	}
+	return string(b), nil
+}
+
+// HashPassword returns the bcrypt hash of the password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether the password matches the hash.
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IsStaff reports whether the user is a dispatcher or an admin.
+func (u User) IsStaff() bool {
+	return u.Role == models.RoleDispatcher || u.Role == models.RoleAdmin
+}
+
+// CanChangeTask reports whether the user may change the fields of the task.
+// Drivers may only change the status of the tasks of the bus of their
+// current shift, the staff may change everything.
+func CanChangeTask(user User, task models.Task, fields ...string) bool {
+	if user.IsStaff() {
+		return true
+	}
+	if user.Role != models.RoleDriver || user.BusID == 0 || task.BusID != user.BusID {
+		return false
+	}
+	for _, field := range fields {
+		if field != "status" {
+			return false
+		}
+	}
+	return true
+}
+
+type contextKey struct{}
+
+// WithUser returns a copy of the context carrying the user.
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// FromContext returns the user put into the context by WithUser.
+func FromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(contextKey{}).(User)
+	return user, ok
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCanChangeTask(t *testing.T) {
+	task := models.Task{Id: 1, BusID: 7}
+
+	tests := []struct {
+		name   string
+		user   User
+		fields []string
+		want   bool
+	}{
+		{name: "dispatcher", user: User{ID: 1, Role: models.RoleDispatcher}, fields: []string{"time", "busID"}, want: true},
+		{name: "admin", user: User{ID: 1, Role: models.RoleAdmin}, fields: []string{"passengers"}, want: true},
+		{name: "driver status", user: User{ID: 2, Role: models.RoleDriver, BusID: 7}, fields: []string{"status"}, want: true},
+		{name: "driver time", user: User{ID: 2, Role: models.RoleDriver, BusID: 7}, fields: []string{"status", "time"}, want: false},
+		{name: "driver of other bus", user: User{ID: 2, Role: models.RoleDriver, BusID: 8}, fields: []string{"status"}, want: false},
+		{name: "driver without shift", user: User{ID: 2, Role: models.RoleDriver}, fields: []string{"status"}, want: false},
+		{name: "no role", user: User{ID: 3, BusID: 7}, fields: []string{"status"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanChangeTask(tt.user, task, tt.fields...); got != tt.want {
+				t.Errorf("CanChangeTask(%+v, %v) = %v, want %v", tt.user, tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickets(t *testing.T) {
+	tickets := NewTickets(time.Minute)
+	user := User{ID: 2, Role: models.RoleDriver, BusID: 7}
+
+	ticket, expires, err := tickets.Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expires.After(time.Now()) {
+		t.Errorf("ticket expires at %s, in the past", expires)
+	}
+
+	got, err := tickets.Redeem(ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (User{ID: 2, Role: models.RoleDriver}); got != want {
+		t.Errorf("Redeem = %+v, want %+v", got, want)
+	}
+
+	if _, err := tickets.Redeem(ticket); !errors.Is(err, ErrInvalidTicket) {
+		t.Errorf("second Redeem error = %v, want %v", err, ErrInvalidTicket)
+	}
+	if _, err := tickets.Redeem("unknown"); !errors.Is(err, ErrInvalidTicket) {
+		t.Errorf("Redeem of unknown ticket error = %v, want %v", err, ErrInvalidTicket)
+	}
+}
+
+func TestTicketsExpire(t *testing.T) {
+	tickets := NewTickets(-time.Second)
+
+	ticket, _, err := tickets.Issue(User{ID: 1, Role: models.RoleDispatcher})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tickets.Redeem(ticket); !errors.Is(err, ErrInvalidTicket) {
+		t.Errorf("Redeem of expired ticket error = %v, want %v", err, ErrInvalidTicket)
+	}
+}
+
+func TestDummyHash(t *testing.T) {
+	// A broken hash would fail at once and give the unknown logins away.
+	cost, err := bcrypt.Cost([]byte(DummyHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+}
+
+func TestNewPIN(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		pin, err := NewPIN()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pin) != pinDigits {
+			t.Errorf("NewPIN() = %q, want %d digits", pin, pinDigits)
+		}
+		for _, c := range pin {
+			if c < '0' || c > '9' {
+				t.Errorf("NewPIN() = %q, want only digits", pin)
+				break
+			}
+		}
+		seen[pin] = true
+	}
+	if len(seen) < 2 {
+		t.Error("NewPIN() returns the same PIN")
+	}
+}
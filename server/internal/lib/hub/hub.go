@@ -0,0 +1,160 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"golang.org/x/exp/slog"
+)
+
+// bufferSize is how many events a subscriber may fall behind before it is
+// dropped. A dropped client reconnects and reloads the tasks.
+const bufferSize = 64
+
+type TaskGetter interface {
+	GetTask(taskID int) (models.Task, error)
+}
+
+type subscription struct {
+	busID  int // 0 for all buses
+	events chan models.TaskEvent
+}
+
+// Hub fans task changes out to the clients streaming them.
+type Hub struct {
+	mu            sync.Mutex
+	subscriptions map[*subscription]struct{}
+}
+
+func New() *Hub {
+	return &Hub{subscriptions: make(map[*subscription]struct{})}
+}
+
+// Subscribe returns the events of the tasks of the bus, of all tasks with a
+// zero busID. The channel is closed by cancel, when the subscriber falls
+// behind or when the hub stops.
+func (h *Hub) Subscribe(busID int) (<-chan models.TaskEvent, func()) {
+	sub := &subscription{busID: busID, events: make(chan models.TaskEvent, bufferSize)}
+
+	h.mu.Lock()
+	h.subscriptions[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sub)
+	}
+}
+
+// Run turns the changes into events until the context is done or the
+// channel is closed. The buses the events go to are taken from the change,
+// created and updated tasks are read from the storage, so that the clients
+// get them in full.
+func (h *Hub) Run(ctx context.Context, log *slog.Logger, changes <-chan models.TaskChange, taskGetter TaskGetter) {
+	defer h.close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			h.dispatch(log, change, taskGetter)
+		}
+	}
+}
+
+// dispatch publishes the events of the change.
+func (h *Hub) dispatch(log *slog.Logger, change models.TaskChange, taskGetter TaskGetter) {
+	moved := change.Op == "UPDATE" && change.PreviousBusID != change.BusID
+	removed := models.TaskEvent{Action: models.TaskActionDelete, Task: models.Task{Id: change.TaskID, BusID: change.PreviousBusID}}
+
+	if change.Op == "DELETE" {
+		h.publish(change.BusID, models.TaskEvent{
+			Action: models.TaskActionDelete,
+			Task:   models.Task{Id: change.TaskID, BusID: change.BusID},
+		})
+		return
+	}
+
+	task, err := taskGetter.GetTask(change.TaskID)
+	if errors.Is(err, taskstorage.ErrTaskNotFound) {
+		// Deleted in the meantime, its own notification follows.
+		if moved {
+			h.publishTo(func(busID int) bool { return busID == change.PreviousBusID }, removed)
+		}
+		return
+	}
+	if err != nil {
+		log.Error("failed to get changed task", slog.Int("taskID", change.TaskID), sl.Err(err))
+		return
+	}
+	if task.BusID != change.BusID {
+		// Moved again in the meantime, its own notification follows and
+		// tells the other buses.
+		if moved {
+			h.publishTo(func(busID int) bool { return busID == change.PreviousBusID }, removed)
+		}
+		return
+	}
+
+	switch {
+	case change.Op == "INSERT":
+		h.publish(change.BusID, models.TaskEvent{Action: models.TaskActionCreate, Task: task})
+	case moved:
+		// For the buses the task moved between it appears and disappears.
+		h.publishTo(func(busID int) bool { return busID == 0 },
+			models.TaskEvent{Action: models.TaskActionUpdate, Task: task})
+		h.publishTo(func(busID int) bool { return busID == change.PreviousBusID }, removed)
+		h.publishTo(func(busID int) bool { return busID == change.BusID },
+			models.TaskEvent{Action: models.TaskActionCreate, Task: task})
+	default:
+		h.publish(change.BusID, models.TaskEvent{Action: models.TaskActionUpdate, Task: task})
+	}
+}
+
+// publish sends the event to the subscribers of all tasks and of the bus.
+func (h *Hub) publish(busID int, event models.TaskEvent) {
+	h.publishTo(func(subscribed int) bool { return subscribed == 0 || subscribed == busID }, event)
+}
+
+func (h *Hub) publishTo(match func(busID int) bool, event models.TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscriptions {
+		if !match(sub.busID) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// remove closes the subscription, h.mu must be held.
+func (h *Hub) remove(sub *subscription) {
+	if _, ok := h.subscriptions[sub]; !ok {
+		return
+	}
+	delete(h.subscriptions, sub)
+	close(sub.events)
+}
+
+func (h *Hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscriptions {
+		h.remove(sub)
+	}
+}
@@ -0,0 +1,162 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"golang.org/x/exp/slog"
+)
+
+// tasks is a storage holding the tasks as they are when the hub reads them.
+type tasks map[int]models.Task
+
+func (t tasks) GetTask(taskID int) (models.Task, error) {
+	task, ok := t[taskID]
+	if !ok {
+		return models.Task{}, fmt.Errorf("get task: %w", taskstorage.ErrTaskNotFound)
+	}
+	return task, nil
+}
+
+func TestHub(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored tasks
+		change models.TaskChange
+		want   map[int][]models.TaskEvent // by subscribed bus, 0 for all
+	}{
+		{
+			name:   "created",
+			stored: tasks{7: {Id: 7, BusID: 1}},
+			change: models.TaskChange{TaskID: 7, Op: "INSERT", BusID: 1, PreviousBusID: 1},
+			want: map[int][]models.TaskEvent{
+				0: {{Action: models.TaskActionCreate, Task: models.Task{Id: 7, BusID: 1}}},
+				1: {{Action: models.TaskActionCreate, Task: models.Task{Id: 7, BusID: 1}}},
+			},
+		},
+		{
+			name:   "updated",
+			stored: tasks{7: {Id: 7, BusID: 2, Passengers: 20}},
+			change: models.TaskChange{TaskID: 7, Op: "UPDATE", BusID: 2, PreviousBusID: 2},
+			want: map[int][]models.TaskEvent{
+				0: {{Action: models.TaskActionUpdate, Task: models.Task{Id: 7, BusID: 2, Passengers: 20}}},
+				2: {{Action: models.TaskActionUpdate, Task: models.Task{Id: 7, BusID: 2, Passengers: 20}}},
+			},
+		},
+		{
+			name:   "moved to another bus",
+			stored: tasks{7: {Id: 7, BusID: 2}},
+			change: models.TaskChange{TaskID: 7, Op: "UPDATE", BusID: 2, PreviousBusID: 1},
+			want: map[int][]models.TaskEvent{
+				0: {{Action: models.TaskActionUpdate, Task: models.Task{Id: 7, BusID: 2}}},
+				1: {{Action: models.TaskActionDelete, Task: models.Task{Id: 7, BusID: 1}}},
+				2: {{Action: models.TaskActionCreate, Task: models.Task{Id: 7, BusID: 2}}},
+			},
+		},
+		{
+			name: "moved again before it was read",
+			// The notification of the move to bus 3 follows.
+			stored: tasks{7: {Id: 7, BusID: 3}},
+			change: models.TaskChange{TaskID: 7, Op: "UPDATE", BusID: 2, PreviousBusID: 1},
+			want: map[int][]models.TaskEvent{
+				1: {{Action: models.TaskActionDelete, Task: models.Task{Id: 7, BusID: 1}}},
+			},
+		},
+		{
+			name:   "deleted",
+			stored: tasks{},
+			change: models.TaskChange{TaskID: 7, Op: "DELETE", BusID: 1, PreviousBusID: 1},
+			want: map[int][]models.TaskEvent{
+				0: {{Action: models.TaskActionDelete, Task: models.Task{Id: 7, BusID: 1}}},
+				1: {{Action: models.TaskActionDelete, Task: models.Task{Id: 7, BusID: 1}}},
+			},
+		},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New()
+			subscribed := make(map[int]<-chan models.TaskEvent)
+			for _, busID := range []int{0, 1, 2, 3} {
+				events, cancel := h.Subscribe(busID)
+				defer cancel()
+				subscribed[busID] = events
+			}
+
+			changes := make(chan models.TaskChange, 1)
+			changes <- tt.change
+			close(changes)
+			// Run returns when the changes are over and closes the subscriptions.
+			h.Run(context.Background(), log, changes, tt.stored)
+
+			for busID, events := range subscribed {
+				var got []models.TaskEvent
+				for event := range events {
+					got = append(got, event)
+				}
+				if !sameEvents(got, tt.want[busID]) {
+					t.Errorf("bus %d got %+v, want %+v", busID, got, tt.want[busID])
+				}
+			}
+		})
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	h := New()
+	slow, cancel := h.Subscribe(0)
+	defer cancel()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	changes := make(chan models.TaskChange)
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), changes, tasks{})
+		close(done)
+	}()
+
+	// One event more than the buffer holds, and another change so that the
+	// overflowing event has been published when the loop ends.
+	for i := 0; i < bufferSize+2; i++ {
+		changes <- models.TaskChange{TaskID: i + 1, Op: "DELETE", BusID: 1}
+	}
+
+	received := 0
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-slow:
+			if !ok {
+				if received != bufferSize {
+					t.Errorf("received %d events before the drop, want %d", received, bufferSize)
+				}
+				stop()
+				<-done
+				return
+			}
+			received++
+		case <-timeout:
+			t.Fatal("slow subscriber was not dropped")
+		}
+	}
+}
+
+func sameEvents(a, b []models.TaskEvent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Action != b[i].Action || a[i].Task.Id != b[i].Task.Id || a[i].Task.BusID != b[i].Task.BusID ||
+			a[i].Task.Passengers != b[i].Task.Passengers {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	_ "github.com/lib/pq"
+)
+
+type LocationStorage struct {
+	db *sql.DB
+}
+
+func New(host, port, user, password, dbname string) (*LocationStorage, error) {
+	const op = "locationstorage.postgresql.New"
+
+	info := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, user, password, dbname)
+	db, err := sql.Open("postgres", info)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &LocationStorage{db: db}, nil
+}
+
+func (s *LocationStorage) AddLocation(location models.Location) error {
+	const op = "locationstorage.postgresql.AddLocation"
+
+	stmt, err := s.db.Prepare("INSERT INTO bus_locations (bus_id, vertex, lat, lon, time) VALUES ($1, $2, $3, $4, $5)")
+	if err != nil {
+		return fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(location.BusID, location.Vertex, location.Lat, location.Lon, location.Time)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetLastLocations returns the last known location of every bus.
+func (s *LocationStorage) GetLastLocations() (map[int]models.Location, error) {
+	const op = "locationstorage.postgresql.GetLastLocations"
+
+	stmt, err := s.db.Prepare("SELECT DISTINCT ON (bus_id) bus_id, vertex, lat, lon, time FROM bus_locations ORDER BY bus_id, time DESC")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	locations := make(map[int]models.Location)
+	for rows.Next() {
+		var location models.Location
+
+		err := rows.Scan(&location.BusID, &location.Vertex, &location.Lat, &location.Lon, &location.Time)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		locations[location.BusID] = location
+	}
+
+	return locations, nil
+}
+
+// GetBusLocations returns the location history of the bus, newest first.
+func (s *LocationStorage) GetBusLocations(busID int, limit int) ([]models.Location, error) {
+	const op = "locationstorage.postgresql.GetBusLocations"
+
+	stmt, err := s.db.Prepare("SELECT bus_id, vertex, lat, lon, time FROM bus_locations WHERE bus_id = $1 ORDER BY time DESC LIMIT $2")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(busID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var locations []models.Location
+	for rows.Next() {
+		var location models.Location
+
+		err := rows.Scan(&location.BusID, &location.Vertex, &location.Lat, &location.Lon, &location.Time)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
@@ -2,6 +2,7 @@ package distancegraph
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/starwander/goraph"
 )
@@ -9,57 +10,221 @@ import (
 type Distancegraph struct {
 	*goraph.Graph
 	minDistances map[string]map[string]float64
+	predecessors map[string]map[string]string
+	lengths      map[string]map[string]float64
+	restrictions map[edge][]Restriction
+	vertices     []Vertex
+	paths        []Path
 }
 
-type path struct {
-	from   string
-	to     string
-	length int
+type edge struct {
+	from string
+	to   string
 }
 
-func New() (*Distancegraph, error) {
-	op := "models.distancegraph.New"
-	// TODO: transfer distances and vertices to config or database
+// Path is a directed edge of the graph, length is in km.
+// A zero length is a missing one, it is replaced with the great-circle
+// distance between the vertices, both of which must have coordinates.
+type Path struct {
+	From   string
+	To     string
+	Length float64
+}
+
+// GraphGetter is a storage of the airport graph.
+type GraphGetter interface {
+	GetVertices() ([]Vertex, error)
+	GetPaths() ([]Path, error)
+	GetRestrictions() ([]Restriction, error)
+}
+
+// New loads the graph from the storage.
+func New(storage GraphGetter) (*Distancegraph, error) {
+	const op = "models.distancegraph.New"
+
+	vertices, err := storage.GetVertices()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	distances, err := storage.GetPaths()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	restrictions, err := storage.GetRestrictions()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	graph, err := Build(vertices, distances, restrictions)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return graph, nil
+}
+
+// Build creates a graph from vertices and edges and precomputes
+// the shortest distances between all pairs of vertices.
+// Every vertex must be reachable from every other vertex.
+func Build(vertices []Vertex, distances []Path, restrictions []Restriction) (*Distancegraph, error) {
+	const op = "models.distancegraph.Build"
 
-	distances := []path{
-		{"A", "A", 0}, {"B", "B", 0}, {"C", "C", 0},
-		{"A", "B", 2}, {"B", "A", 2},
-		{"B", "C", 5}, {"C", "B", 5},
+	if len(vertices) == 0 {
+		return nil, fmt.Errorf("%s: graph has no vertices", op)
 	}
-	vertices := []string{"A", "B", "C"}
 
-	graph := Distancegraph{goraph.NewGraph(), nil}
+	graph := Distancegraph{
+		Graph:        goraph.NewGraph(),
+		lengths:      make(map[string]map[string]float64),
+		restrictions: make(map[edge][]Restriction),
+	}
+	byName := make(map[string]Vertex, len(vertices))
+	names := make([]string, 0, len(vertices))
 	for _, vertex := range vertices {
-		err := graph.AddVertex(vertex, nil)
+		err := graph.AddVertex(vertex.Name, vertex)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		byName[vertex.Name] = vertex
+		names = append(names, vertex.Name)
 	}
 
 	for _, path := range distances {
-		err := graph.AddEdge(path.from, path.to, float64(path.length), nil)
+		if path.From == path.To {
+			continue
+		}
+		if path.Length < 0 {
+			return nil, fmt.Errorf("%s: negative length of edge %s-%s", op, path.From, path.To)
+		}
+		if path.Length == 0 {
+			from, to := byName[path.From], byName[path.To]
+			if !from.HasCoordinates() || !to.HasCoordinates() {
+				return nil, fmt.Errorf("%s: edge %s-%s has no length and its vertices have no coordinates", op, path.From, path.To)
+			}
+			path.Length = Haversine(from, to)
+		}
+		graph.paths = append(graph.paths, path)
+
+		err := graph.AddEdge(path.From, path.To, path.Length, nil)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+
+		if graph.lengths[path.From] == nil {
+			graph.lengths[path.From] = make(map[string]float64)
+		}
+		graph.lengths[path.From][path.To] = path.Length
+	}
+
+	for _, r := range restrictions {
+		if _, ok := graph.lengths[r.From][r.To]; !ok {
+			return nil, fmt.Errorf("%s: restriction on unknown edge %s-%s", op, r.From, r.To)
+		}
+		if !r.End.After(r.Start) || r.MaxSpeed < 0 {
+			return nil, fmt.Errorf("%s: wrong restriction on edge %s-%s", op, r.From, r.To)
+		}
+		key := edge{r.From, r.To}
+		graph.restrictions[key] = append(graph.restrictions[key], r)
 	}
 
 	minDistances := make(map[string]map[string]float64)
-	for _, from := range vertices {
-		dist, _, err := graph.Dijkstra(from)
+	predecessors := make(map[string]map[string]string)
+	for _, from := range names {
+		dist, prev, err := graph.Dijkstra(from)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		vertexPredecessors := make(map[string]string)
+		for to, p := range prev {
+			if p != nil {
+				vertexPredecessors[to.(string)] = p.(string)
+			}
+		}
+		predecessors[from] = vertexPredecessors
+
 		vertexDistances := make(map[string]float64)
-		for _, to := range vertices {
+		for _, to := range names {
+			if math.IsInf(dist[to], 1) {
+				return nil, fmt.Errorf("%s: vertex %s is unreachable from %s", op, to, from)
+			}
 			vertexDistances[to] = dist[to]
 		}
 		minDistances[from] = vertexDistances
 	}
 	graph.minDistances = minDistances
+	graph.predecessors = predecessors
+	graph.vertices = vertices
 
 	return &graph, nil
 }
 
+// MinDistance returns the shortest distance between two vertices,
+// or +Inf if either vertex is unknown or unreachable.
 func (g *Distancegraph) MinDistance(from string, to string) float64 {
-	return g.minDistances[from][to]
+	dist, ok := g.minDistances[from][to]
+	if !ok {
+		return math.Inf(1)
+	}
+	return dist
+}
+
+// HasVertex reports whether the graph has a vertex with the name.
+func (g *Distancegraph) HasVertex(name string) bool {
+	_, ok := g.minDistances[name]
+	return ok
+}
+
+// Vertices returns all vertices of the graph.
+func (g *Distancegraph) Vertices() []Vertex {
+	return g.vertices
+}
+
+// Nearest returns the vertex closest to the given coordinates,
+// false if no vertex has coordinates.
+func (g *Distancegraph) Nearest(lat float64, lon float64) (Vertex, bool) {
+	point := Vertex{Lat: lat, Lon: lon}
+
+	var nearest Vertex
+	found := false
+	minDistance := math.Inf(1)
+	for _, vertex := range g.vertices {
+		if !vertex.HasCoordinates() {
+			continue
+		}
+		if distance := Haversine(point, vertex); distance < minDistance {
+			nearest, minDistance, found = vertex, distance, true
+		}
+	}
+
+	return nearest, found
+}
+
+// Paths returns all edges of the graph.
+func (g *Distancegraph) Paths() []Path {
+	return g.paths
+}
+
+// Route returns the vertices of the shortest path from one vertex to another,
+// both ends included, or nil if there is no path.
+func (g *Distancegraph) Route(from string, to string) []string {
+	if math.IsInf(g.MinDistance(from, to), 0) {
+		return nil
+	}
+	return buildRoute(g.predecessors[from], from, to)
+}
+
+func buildRoute(predecessors map[string]string, from string, to string) []string {
+	route := []string{to}
+	for vertex := to; vertex != from; {
+		vertex = predecessors[vertex]
+		route = append(route, vertex)
+	}
+
+	for i, j := 0, len(route)-1; i < j; i, j = i+1, j-1 {
+		route[i], route[j] = route[j], route[i]
+	}
+
+	return route
 }
@@ -0,0 +1,53 @@
+package distancegraph
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildLengths(t *testing.T) {
+	located := []Vertex{
+		{Name: "A", Lat: 55.97, Lon: 37.41},
+		{Name: "B", Lat: 55.98, Lon: 37.42},
+	}
+	unlocated := []Vertex{{Name: "A"}, {Name: "B"}}
+
+	tests := []struct {
+		name     string
+		vertices []Vertex
+		length   float64
+		wantErr  bool
+	}{
+		{name: "given length", vertices: unlocated, length: 1.5},
+		{name: "length from coordinates", vertices: located, length: 0},
+		{name: "missing length without coordinates", vertices: unlocated, length: 0, wantErr: true},
+		{name: "negative length", vertices: located, length: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := []Path{
+				{From: "A", To: "B", Length: tt.length},
+				{From: "B", To: "A", Length: tt.length},
+			}
+			graph, err := Build(tt.vertices, paths, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Build() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+
+			want := tt.length
+			if want == 0 {
+				want = Haversine(tt.vertices[0], tt.vertices[1])
+			}
+			if got := graph.MinDistance("A", "B"); math.Abs(got-want) > 1e-9 {
+				t.Errorf("MinDistance() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package distancegraph
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Holder keeps the current graph and replaces it at runtime.
+// Readers always get a complete graph: a new one is built aside
+// and swapped in atomically.
+type Holder struct {
+	storage GraphGetter
+	graph   atomic.Pointer[Distancegraph]
+	mu      sync.Mutex
+}
+
+// NewHolder loads the graph from the storage.
+func NewHolder(storage GraphGetter) (*Holder, error) {
+	const op = "models.distancegraph.NewHolder"
+
+	h := &Holder{storage: storage}
+	if err := h.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return h, nil
+}
+
+// Graph returns the current graph.
+func (h *Holder) Graph() *Distancegraph {
+	return h.graph.Load()
+}
+
+// Reload rebuilds the graph from the storage. On error the current
+// graph is kept.
+func (h *Holder) Reload() error {
+	const op = "models.distancegraph.Reload"
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	graph, err := New(h.storage)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	h.graph.Store(graph)
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package distancegraph
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+// BusSpeed is the speed of a bus on an unrestricted edge.
+const BusSpeed = 45 // km/h
+
+// Restriction limits traffic on the edge From-To between Start and End.
+// A zero MaxSpeed closes the edge.
+type Restriction struct {
+	From     string
+	To       string
+	Start    time.Time
+	End      time.Time
+	MaxSpeed float64 // km/h
+}
+
+// TravelTime returns how long a bus leaving from at the departure time drives
+// to the vertex to, and false if to is unreachable. Restrictions are applied
+// at the moment the bus enters an edge: a closed edge is waited out,
+// a speed limit holds for the whole edge.
+func (g *Distancegraph) TravelTime(from string, to string, departure time.Time) (time.Duration, bool) {
+	_, travel, ok := g.TimedRoute(from, to, departure)
+	return travel, ok
+}
+
+// TimedRoute returns the fastest route for a bus leaving from at the
+// departure time together with its travel time, see TravelTime.
+func (g *Distancegraph) TimedRoute(from string, to string, departure time.Time) ([]string, time.Duration, bool) {
+	distance := g.MinDistance(from, to)
+	if math.IsInf(distance, 0) {
+		return nil, 0, false
+	}
+
+	static := driveTime(distance, BusSpeed)
+	if !g.restricted(departure, departure.Add(static)) {
+		return g.Route(from, to), static, true
+	}
+
+	route, arrival, ok := g.earliestArrival(from, to, departure)
+	if !ok {
+		return nil, 0, false
+	}
+	return route, arrival.Sub(departure), true
+}
+
+// restricted reports whether any restriction overlaps the time window.
+// Outside of restrictions the static shortest path is the fastest one.
+func (g *Distancegraph) restricted(start, end time.Time) bool {
+	for _, restrictions := range g.restrictions {
+		for _, r := range restrictions {
+			if r.Start.Before(end) && r.End.After(start) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// earliestArrival is a time-dependent Dijkstra. Waiting for a closed edge to
+// open never makes a bus arrive earlier, so the FIFO property holds.
+func (g *Distancegraph) earliestArrival(from string, to string, departure time.Time) ([]string, time.Time, bool) {
+	arrival := map[string]time.Time{from: departure}
+	predecessors := make(map[string]string)
+	done := make(map[string]bool)
+
+	queue := &arrivalQueue{{vertex: from, at: departure}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(arrivalItem)
+		if done[current.vertex] {
+			continue
+		}
+		if current.vertex == to {
+			return buildRoute(predecessors, from, to), current.at, true
+		}
+		done[current.vertex] = true
+
+		for next, length := range g.lengths[current.vertex] {
+			if done[next] {
+				continue
+			}
+			at := g.traverse(current.vertex, next, length, current.at)
+			if known, ok := arrival[next]; !ok || at.Before(known) {
+				arrival[next] = at
+				predecessors[next] = current.vertex
+				heap.Push(queue, arrivalItem{vertex: next, at: at})
+			}
+		}
+	}
+
+	return nil, time.Time{}, false
+}
+
+// traverse returns when a bus entering the edge at the given time leaves it.
+func (g *Distancegraph) traverse(from string, to string, length float64, at time.Time) time.Time {
+	restrictions := g.restrictions[edge{from, to}]
+
+	speed := float64(BusSpeed)
+	for waited := true; waited; {
+		waited = false
+		speed = BusSpeed
+		for _, r := range restrictions {
+			if at.Before(r.Start) || !at.Before(r.End) {
+				continue
+			}
+			if r.MaxSpeed == 0 {
+				at = r.End
+				waited = true
+				break
+			}
+			speed = math.Min(speed, r.MaxSpeed)
+		}
+	}
+
+	return at.Add(driveTime(length, speed))
+}
+
+func driveTime(distance float64, speed float64) time.Duration {
+	return time.Duration(distance / speed * float64(time.Hour))
+}
+
+type arrivalItem struct {
+	vertex string
+	at     time.Time
+}
+
+type arrivalQueue []arrivalItem
+
+func (q arrivalQueue) Len() int           { return len(q) }
+func (q arrivalQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q arrivalQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *arrivalQueue) Push(x any) {
+	*q = append(*q, x.(arrivalItem))
+}
+
+func (q *arrivalQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,37 @@
+package distancegraph
+
+import "math"
+
+const (
+	VertexStand   = "stand"   // aircraft stand
+	VertexGate    = "gate"    // terminal gate
+	VertexParking = "parking" // bus parking
+	VertexDepot   = "depot"   // bus depot
+
+	earthRadius = 6371 // km
+)
+
+// Vertex is a point of the airport. Coordinates are in degrees,
+// a vertex without coordinates has zero Lat and Lon.
+type Vertex struct {
+	Name string
+	Type string
+	Lat  float64
+	Lon  float64
+}
+
+func (v Vertex) HasCoordinates() bool {
+	return v.Lat != 0 || v.Lon != 0
+}
+
+// Haversine returns the great-circle distance between two vertices in km.
+func Haversine(from Vertex, to Vertex) float64 {
+	lat1, lat2 := from.Lat*math.Pi/180, to.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (to.Lon - from.Lon) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -2,25 +2,373 @@ package models
 
 import "time"
 
+const (
+	BusStatusWork         = "in work"
+	BusStatusBreak        = "on break"
+	BusStatusOutOfService = "out of service"
+	BusStatusOffDuty      = "off duty"
+
+	TaskStatusWork      = "in work"
+	TaskStatusPause     = "on pause"
+	TaskStatusComplete  = "complete"
+	TaskStatusQueue     = "queue"
+	TaskStatusCancelled = "cancelled"
+
+	FlightStatusScheduled = "scheduled"
+	FlightStatusCancelled = "cancelled"
+
+	LegEmpty     = "empty"     // the bus drives to the pickup point
+	LegBoarding  = "boarding"  // the passengers board the bus
+	LegLoaded    = "loaded"    // the bus carries the passengers
+	LegUnloading = "unloading" // the passengers leave the bus
+	LegReturn    = "return"    // the bus drives back to its parking
+
+	IncidentBreakdown = "breakdown"
+	IncidentAccident  = "accident"
+	IncidentPassenger = "passenger"
+
+	IncidentStatusOpen     = "open"
+	IncidentStatusResolved = "resolved"
+
+	RoleDriver     = "driver"
+	RoleDispatcher = "dispatcher"
+	RoleAdmin      = "admin"
+)
+
+// busTransitions lists the statuses a bus may move to from each status.
+var busTransitions = map[string][]string{
+	BusStatusOffDuty:      {BusStatusWork},
+	BusStatusWork:         {BusStatusBreak, BusStatusOutOfService, BusStatusOffDuty},
+	BusStatusBreak:        {BusStatusWork, BusStatusOutOfService},
+	BusStatusOutOfService: {BusStatusWork, BusStatusOffDuty},
+}
+
+// CanChangeBusStatus reports whether a bus may move from one status to another.
+func CanChangeBusStatus(from string, to string) bool {
+	for _, status := range busTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// taskTransitions lists the statuses a task may move to from each status.
+// Complete and cancelled tasks are over.
+var taskTransitions = map[string][]string{
+	TaskStatusQueue: {TaskStatusWork, TaskStatusCancelled},
+	TaskStatusWork:  {TaskStatusPause, TaskStatusComplete, TaskStatusCancelled},
+	TaskStatusPause: {TaskStatusWork, TaskStatusComplete, TaskStatusCancelled},
+}
+
+// IsTaskStatus reports whether the status is one of the task statuses.
+func IsTaskStatus(status string) bool {
+	switch status {
+	case TaskStatusQueue, TaskStatusWork, TaskStatusPause, TaskStatusComplete, TaskStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsFlightStatus reports whether the status is one of the flight statuses.
+func IsFlightStatus(status string) bool {
+	switch status {
+	case FlightStatusScheduled, FlightStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// CanChangeTaskStatus reports whether a task may move from one status to another.
+func CanChangeTaskStatus(from string, to string) bool {
+	for _, status := range taskTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Bus struct {
-	Id      int
-	Status  string
-	Parking string
+	Id         int    `json:"id"`
+	Status     string `json:"status"`
+	Parking    string `json:"parking"`
+	Capacity   int    `json:"capacity"`   // passengers
+	Type       string `json:"type"`       // vehicle model, e.g. "Cobus 3000"
+	Accessible bool   `json:"accessible"` // suitable for passengers with reduced mobility
 }
 
+// Flight directions. Passengers of an arrival are carried from the aircraft
+// stand to the terminal gate, passengers of a departure the other way round.
+const (
+	FlightArrival   = "A"
+	FlightDeparture = "D"
+)
+
 type Flight struct {
-	Id          int
-	Destination string
-	Time        time.Time
-	Status      string
-	Passengers  int
+	Id            int        `json:"id"`
+	Number        string     `json:"number"`
+	Direction     string     `json:"direction"`
+	Date          string     `json:"date"` // 2006-01-02, day of the flight at its origin by schedule
+	Stand         string     `json:"stand"`
+	Gate          string     `json:"gate"`
+	AircraftType  string     `json:"aircraft type"`
+	ScheduledTime time.Time  `json:"scheduled time"`
+	EstimatedTime *time.Time `json:"estimated time,omitempty"`
+	ActualTime    *time.Time `json:"actual time,omitempty"`
+	Status        string     `json:"status"`
+	Passengers    int        `json:"passengers"`
+}
+
+// Time returns the best known time of the flight: actual, estimated or scheduled.
+func (f Flight) Time() time.Time {
+	switch {
+	case f.ActualTime != nil:
+		return *f.ActualTime
+	case f.EstimatedTime != nil:
+		return *f.EstimatedTime
+	default:
+		return f.ScheduledTime
+	}
+}
+
+// Pickup returns the vertex where the passengers board the bus.
+func (f Flight) Pickup() string {
+	if f.Direction == FlightDeparture {
+		return f.Gate
+	}
+	return f.Stand
+}
+
+// Dropoff returns the vertex where the passengers leave the bus.
+func (f Flight) Dropoff() string {
+	if f.Direction == FlightDeparture {
+		return f.Stand
+	}
+	return f.Gate
 }
 
 type Task struct {
-	Id        int       `json:"id"`
-	BusID     int       `json:"busID"`
-	FlightID  int       `json:"flightID"`
-	TimeStart time.Time `json:"time start"`
-	TimeEnd   time.Time `json:"time end"`
-	Status    string    `json:"status"`
+	Id         int       `json:"id"`
+	BusID      int       `json:"busID"`
+	FlightID   int       `json:"flightID"`
+	Passengers int       `json:"passengers"`
+	TimeStart  time.Time `json:"time start"`
+	TimeEnd    time.Time `json:"time end"`
+	Status     string    `json:"status"`
+	From       string    `json:"from"`  // where the passengers board the bus
+	To         string    `json:"to"`    // where the passengers leave the bus
+	Route      []string  `json:"route"` // vertices from the bus location to the pickup point
+	Legs       []Leg     `json:"legs"`
+	Version    int       `json:"version"` // grows with every change of the task
+}
+
+// Leg is a phase of a task. Actual times are nil until the driver
+// reports them.
+type Leg struct {
+	Kind         string     `json:"kind"`
+	From         string     `json:"from"`
+	To           string     `json:"to"`
+	Route        []string   `json:"route,omitempty"`
+	PlannedStart time.Time  `json:"planned start"`
+	PlannedEnd   time.Time  `json:"planned end"`
+	ActualStart  *time.Time `json:"actual start,omitempty"`
+	ActualEnd    *time.Time `json:"actual end,omitempty"`
+}
+
+// Leg returns the first leg of the kind.
+func (t Task) Leg(kind string) (*Leg, bool) {
+	for i := range t.Legs {
+		if t.Legs[i].Kind == kind {
+			return &t.Legs[i], true
+		}
+	}
+	return nil, false
+}
+
+// Shift moves the task and the planned times of its legs by the duration.
+// The times the driver reported are kept.
+func (t *Task) Shift(d time.Duration) {
+	t.TimeStart = t.TimeStart.Add(d)
+	t.TimeEnd = t.TimeEnd.Add(d)
+	// The legs may be shared with other copies of the task.
+	t.Legs = append([]Leg(nil), t.Legs...)
+	for i := range t.Legs {
+		t.Legs[i].PlannedStart = t.Legs[i].PlannedStart.Add(d)
+		t.Legs[i].PlannedEnd = t.Legs[i].PlannedEnd.Add(d)
+	}
+}
+
+// Phase returns the kind of the leg the bus is in, or an empty string
+// if no leg has started or the task is over.
+func (t Task) Phase() string {
+	for _, leg := range t.Legs {
+		if leg.ActualStart != nil && leg.ActualEnd == nil {
+			return leg.Kind
+		}
+	}
+	return ""
+}
+
+// Location is a position reported by the driver of a bus.
+type Location struct {
+	BusID  int       `json:"busID"`
+	Vertex string    `json:"vertex"`
+	Lat    float64   `json:"lat"`
+	Lon    float64   `json:"lon"`
+	Time   time.Time `json:"time"`
+}
+
+// Break is a rest of the driver of a bus. TimeEnd is nil while the break lasts.
+type Break struct {
+	Id         int        `json:"id"`
+	BusID      int        `json:"busID"`
+	TimeStart  time.Time  `json:"time start"`
+	PlannedEnd time.Time  `json:"planned end"`
+	TimeEnd    *time.Time `json:"time end,omitempty"`
+}
+
+// FlightChange is a notification that a flight was added, changed or
+// deleted. Op is one of INSERT, UPDATE and DELETE.
+type FlightChange struct {
+	FlightID int    `json:"id"`
+	Op       string `json:"op"`
+}
+
+// FlightUpdate is a change of a flight pushed by the operational database of
+// the airport. The flight is found by its number, direction and date, see
+// Flight.Date. Nil fields are left as they are. Time is when the change
+// was made at the source: a field is only overwritten by a newer change.
+type FlightUpdate struct {
+	Number        string     `json:"number"`
+	Direction     string     `json:"direction"`
+	Date          string     `json:"date"`
+	Time          time.Time  `json:"time"`
+	EstimatedTime *time.Time `json:"estimated time,omitempty"`
+	ActualTime    *time.Time `json:"actual time,omitempty"`
+	Stand         *string    `json:"stand,omitempty"`
+	Gate          *string    `json:"gate,omitempty"`
+	Status        *string    `json:"status,omitempty"`
+	Passengers    *int       `json:"passengers,omitempty"`
+}
+
+// Apply writes the fields of the update that are newer than the last change
+// of the same field to the flight, the last writer wins. FieldTimes holds the
+// time of the last change of every field by its column name and is updated
+// too. It reports whether the flight changed.
+func (u FlightUpdate) Apply(flight *Flight, fieldTimes map[string]time.Time) bool {
+	changed := false
+	newer := func(field string) bool {
+		if last, ok := fieldTimes[field]; ok && !u.Time.After(last) {
+			return false
+		}
+		fieldTimes[field] = u.Time
+		changed = true
+		return true
+	}
+
+	if u.EstimatedTime != nil && newer("estimated_time") {
+		estimated := u.EstimatedTime.Local()
+		flight.EstimatedTime = &estimated
+	}
+	if u.ActualTime != nil && newer("actual_time") {
+		actual := u.ActualTime.Local()
+		flight.ActualTime = &actual
+	}
+	if u.Stand != nil && newer("stand") {
+		flight.Stand = *u.Stand
+	}
+	if u.Gate != nil && newer("gate") {
+		flight.Gate = *u.Gate
+	}
+	if u.Status != nil && newer("status") {
+		flight.Status = *u.Status
+	}
+	if u.Passengers != nil && newer("passengers") {
+		flight.Passengers = *u.Passengers
+	}
+
+	return changed
+}
+
+// TaskChange is a notification that a task was added, changed or deleted.
+// Op is one of INSERT, UPDATE and DELETE. PreviousBusID differs from BusID
+// when an update moved the task to another bus.
+type TaskChange struct {
+	TaskID        int    `json:"id"`
+	Op            string `json:"op"`
+	BusID         int    `json:"busID"`
+	PreviousBusID int    `json:"previousBusID"`
+}
+
+// Task event actions, the same as actionBack of the web dispatcher.
+const (
+	TaskActionUpdate = 0
+	TaskActionDelete = 1
+	TaskActionCreate = 2
+)
+
+// TaskEvent is a change of a task pushed to the clients. A deleted task
+// only has its id and bus.
+type TaskEvent struct {
+	Action int  `json:"action"`
+	Task   Task `json:"task"`
+}
+
+// TaskRecord is a change of a task in its history: who changed the field,
+// when, and from what to what. Field is created or deleted for the whole
+// task, those have only the new or the old status. UserID is zero and Role
+// is scheduler for the changes made by the scheduler.
+type TaskRecord struct {
+	Id       int       `json:"id"`
+	TaskID   int       `json:"taskID"`
+	Time     time.Time `json:"time"`
+	UserID   int       `json:"userID"`
+	Role     string    `json:"role"`
+	Field    string    `json:"field"`
+	OldValue *string   `json:"old value,omitempty"`
+	NewValue *string   `json:"new value,omitempty"`
+}
+
+// Incident is a force majeure reported by the driver of a bus.
+// TaskID is zero if the bus had no task at the moment.
+type Incident struct {
+	Id           int        `json:"id"`
+	BusID        int        `json:"busID"`
+	TaskID       int        `json:"taskID"`
+	Type         string     `json:"type"`
+	Description  string     `json:"description"`
+	Status       string     `json:"status"`
+	TimeCreated  time.Time  `json:"time created"`
+	TimeResolved *time.Time `json:"time resolved,omitempty"`
+	Resolution   string     `json:"resolution,omitempty"`
+}
+
+// Account is a dispatcher or an admin signing in with a password.
+type Account struct {
+	Id           int    `json:"id"`
+	Login        string `json:"login"`
+	Role         string `json:"role"`
+	PasswordHash string `json:"-"`
+}
+
+// Driver is a bus driver signing in to the mobile app with the id, the name
+// and the PIN issued by the dispatcher. PinHash is empty until a PIN is
+// issued.
+type Driver struct {
+	Id      int    `json:"id"`
+	Name    string `json:"name"`
+	PinHash string `json:"-"`
+}
+
+// Shift is a time window when the driver drives the bus. The driver checks
+// in on arrival and checks out on leaving, both times are nil until then.
+type Shift struct {
+	Id         int        `json:"id"`
+	DriverID   int        `json:"driverID"`
+	BusID      int        `json:"busID"`
+	TimeStart  time.Time  `json:"time start"`
+	TimeEnd    time.Time  `json:"time end"`
+	CheckedIn  *time.Time `json:"checked in,omitempty"`
+	CheckedOut *time.Time `json:"checked out,omitempty"`
 }
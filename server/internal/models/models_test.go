@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskShift(t *testing.T) {
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	reported := base.Add(time.Minute)
+	legs := []Leg{
+		{Kind: LegEmpty, PlannedStart: base, PlannedEnd: base.Add(10 * time.Minute), ActualStart: &reported},
+		{Kind: LegBoarding, PlannedStart: base.Add(10 * time.Minute), PlannedEnd: base.Add(20 * time.Minute)},
+	}
+	task := Task{TimeStart: base, TimeEnd: base.Add(20 * time.Minute), Legs: legs}
+
+	task.Shift(15 * time.Minute)
+
+	if want := base.Add(15 * time.Minute); !task.TimeStart.Equal(want) {
+		t.Errorf("TimeStart = %s, want %s", task.TimeStart, want)
+	}
+	if want := base.Add(35 * time.Minute); !task.TimeEnd.Equal(want) {
+		t.Errorf("TimeEnd = %s, want %s", task.TimeEnd, want)
+	}
+	for i, leg := range task.Legs {
+		if want := legs[i].PlannedStart.Add(15 * time.Minute); !leg.PlannedStart.Equal(want) {
+			t.Errorf("leg %s starts at %s, want %s", leg.Kind, leg.PlannedStart, want)
+		}
+		if want := legs[i].PlannedEnd.Add(15 * time.Minute); !leg.PlannedEnd.Equal(want) {
+			t.Errorf("leg %s ends at %s, want %s", leg.Kind, leg.PlannedEnd, want)
+		}
+	}
+	if task.Legs[0].ActualStart != &reported {
+		t.Error("the reported start was changed")
+	}
+	if !legs[0].PlannedStart.Equal(base) {
+		t.Error("the legs of the original task were changed")
+	}
+}
+
+func TestCanChangeTaskStatus(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: TaskStatusQueue, to: TaskStatusWork, want: true},
+		{from: TaskStatusQueue, to: TaskStatusCancelled, want: true},
+		{from: TaskStatusQueue, to: TaskStatusPause, want: false},
+		{from: TaskStatusQueue, to: TaskStatusComplete, want: false},
+		{from: TaskStatusWork, to: TaskStatusPause, want: true},
+		{from: TaskStatusWork, to: TaskStatusComplete, want: true},
+		{from: TaskStatusWork, to: TaskStatusCancelled, want: true},
+		{from: TaskStatusWork, to: TaskStatusQueue, want: false},
+		{from: TaskStatusPause, to: TaskStatusWork, want: true},
+		{from: TaskStatusPause, to: TaskStatusComplete, want: true},
+		{from: TaskStatusPause, to: TaskStatusQueue, want: false},
+		{from: TaskStatusComplete, to: TaskStatusQueue, want: false},
+		{from: TaskStatusComplete, to: TaskStatusWork, want: false},
+		{from: TaskStatusCancelled, to: TaskStatusQueue, want: false},
+		{from: TaskStatusWork, to: TaskStatusWork, want: false},
+		{from: "unknown", to: TaskStatusWork, want: false},
+		{from: TaskStatusQueue, to: "unknown", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := CanChangeTaskStatus(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanChangeTaskStatus(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+// Greedy assigns every bus in turn to the earliest flight it can still reach.
+// Larger buses are planned first.
+type Greedy struct{}
+
+func (Greedy) Schedule(flights []models.Flight, buses []BusState, graph *distancegraph.Distancegraph, times ServiceTimes) []models.Task {
+	// Идея алгоритма:
+
+	// Цикл по всем активным автобусам:
+	//     Создаем переменные: список задач, время последней задачи автобуса, локация последней задачи автобуса
+	//     Находим ближайший по времени рейс, на который успевает автобус (используем граф для подсчета расстояния и времени)
+	//     Добавляем задачу автобусу, меняем его время и позицию.
+	//     Из количества пассажиров рейса вычитаем количество пассажиров автобусов
+	//	   Если пассажиров больше не осталось, больше не учитываем этот рейс
+	//     Если нет задачи, удовлетворяющей автобусу, то переходим с следующему
+	// Сложность алгоритма: O(n*m), где n - число автобусов, m - число рейсов
+
+	// Flights that cannot be served are left unassigned.
+	pickups := make(map[int]time.Time, len(flights))
+	sortedFlights := make([]models.Flight, 0, len(flights))
+	for _, flight := range flights {
+		if pickup, ok := pickupAt(graph, times, flight); ok {
+			pickups[flight.Id] = pickup
+			sortedFlights = append(sortedFlights, flight)
+		}
+	}
+	sort.Slice(sortedFlights, func(i, j int) bool {
+		return pickups[sortedFlights[i].Id].Before(pickups[sortedFlights[j].Id])
+	})
+
+	passengersLeft := make(map[int]int, len(sortedFlights))
+	for _, flight := range sortedFlights {
+		passengersLeft[flight.Id] = flight.Passengers
+	}
+
+	var tasks []models.Task
+	for _, bus := range byCapacity(buses) {
+		if bus.Status != models.BusStatusWork {
+			continue
+		}
+
+		busTime := bus.FreeAt
+		busLocation := bus.Location
+
+		for {
+			found := false
+			for _, flight := range sortedFlights {
+				if passengersLeft[flight.Id] <= 0 {
+					continue
+				}
+
+				pickup := pickups[flight.Id]
+				start, ok := departureTime(graph, busLocation, flight.Pickup(), busTime, pickup)
+				if !ok {
+					continue
+				}
+
+				passengers := capacity(bus)
+				if passengers > passengersLeft[flight.Id] {
+					passengers = passengersLeft[flight.Id]
+				}
+
+				task, ok := newTask(graph, times, bus.Id, flight, passengers, busLocation, start, pickup)
+				if !ok {
+					continue
+				}
+				tasks = append(tasks, task)
+
+				passengersLeft[flight.Id] -= passengers
+				busTime = task.TimeEnd
+				busLocation = flight.Dropoff()
+				found = true
+				break
+			}
+
+			if !found {
+				break
+			}
+		}
+	}
+
+	return tasks
+}
@@ -0,0 +1,244 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+const (
+	boardingTime  = 10 * time.Minute // default time for the passengers to board a bus
+	unloadingTime = 5 * time.Minute  // default time for the passengers to leave a bus
+)
+
+// pickupAt returns when the passengers of the flight board the bus. Arriving
+// passengers board when the aircraft arrives, departing passengers so that
+// they are at the aircraft departureLead before it leaves. False means the
+// stand cannot be reached from the gate.
+func pickupAt(graph *distancegraph.Distancegraph, times ServiceTimes, flight models.Flight) (time.Time, bool) {
+	if flight.Direction != models.FlightDeparture {
+		return flight.Time(), true
+	}
+
+	service := times.For(flight)
+	deadline := flight.Time().Add(-departureLead - service.Unloading)
+	travel, ok := graph.TravelTime(flight.Pickup(), flight.Dropoff(), deadline)
+	if !ok {
+		return time.Time{}, false
+	}
+	return deadline.Add(-travel - service.Boarding), true
+}
+
+// passengerLegs returns the boarding, loaded and unloading legs of a trip of
+// the flight whose passengers board at the pickup time, false if the
+// drop-off cannot be reached from the pickup.
+func passengerLegs(graph *distancegraph.Distancegraph, times ServiceTimes, flight models.Flight, pickup time.Time) ([]models.Leg, bool) {
+	service := times.For(flight)
+	boarded := pickup.Add(service.Boarding)
+	route, travel, ok := graph.TimedRoute(flight.Pickup(), flight.Dropoff(), boarded)
+	if !ok {
+		return nil, false
+	}
+	arrived := boarded.Add(travel)
+
+	return []models.Leg{
+		{Kind: models.LegBoarding, From: flight.Pickup(), To: flight.Pickup(), PlannedStart: pickup, PlannedEnd: boarded},
+		{Kind: models.LegLoaded, From: flight.Pickup(), To: flight.Dropoff(), Route: route, PlannedStart: boarded, PlannedEnd: arrived},
+		{Kind: models.LegUnloading, From: flight.Dropoff(), To: flight.Dropoff(), PlannedStart: arrived, PlannedEnd: arrived.Add(service.Unloading)},
+	}, true
+}
+
+// tripLegs returns the pickup time and the passenger legs of a trip of the
+// flight, false if the flight cannot be served.
+func tripLegs(graph *distancegraph.Distancegraph, times ServiceTimes, flight models.Flight) (time.Time, []models.Leg, bool) {
+	pickup, ok := pickupAt(graph, times, flight)
+	if !ok {
+		return time.Time{}, nil, false
+	}
+	legs, ok := passengerLegs(graph, times, flight, pickup)
+	if !ok {
+		return time.Time{}, nil, false
+	}
+	return pickup, legs, true
+}
+
+// driveLeg returns the leg of a bus leaving from at the departure time,
+// false if the vertex to cannot be reached.
+func driveLeg(graph *distancegraph.Distancegraph, kind string, from string, to string, departure time.Time) (models.Leg, bool) {
+	route, travel, ok := graph.TimedRoute(from, to, departure)
+	if !ok {
+		return models.Leg{}, false
+	}
+	return models.Leg{Kind: kind, From: from, To: to, Route: route, PlannedStart: departure, PlannedEnd: departure.Add(travel)}, true
+}
+
+// newTask builds a queued task of the bus that leaves from at the start time
+// to carry the passengers of the flight who board at the pickup time. False
+// means the bus cannot drive the task.
+func newTask(graph *distancegraph.Distancegraph, times ServiceTimes, busID int, flight models.Flight, passengers int, from string, start time.Time, pickup time.Time) (models.Task, bool) {
+	empty, ok := driveLeg(graph, models.LegEmpty, from, flight.Pickup(), start)
+	if !ok {
+		return models.Task{}, false
+	}
+	trip, ok := passengerLegs(graph, times, flight, pickup)
+	if !ok {
+		return models.Task{}, false
+	}
+	legs := append([]models.Leg{empty}, trip...)
+
+	return models.Task{
+		BusID:      busID,
+		FlightID:   flight.Id,
+		Passengers: passengers,
+		TimeStart:  start,
+		TimeEnd:    legs[len(legs)-1].PlannedEnd,
+		Status:     models.TaskStatusQueue,
+		From:       flight.Pickup(),
+		To:         flight.Dropoff(),
+		Route:      empty.Route,
+		Legs:       legs,
+	}, true
+}
+
+// setStart makes the task start from the vertex at the given time, false if
+// the pickup cannot be reached from there.
+func setStart(graph *distancegraph.Distancegraph, task *models.Task, from string, start time.Time) bool {
+	empty, ok := driveLeg(graph, models.LegEmpty, from, task.From, start)
+	if !ok {
+		return false
+	}
+
+	task.TimeStart = start
+	task.Route = empty.Route
+	// The legs may be shared with other copies of the task.
+	task.Legs = append([]models.Leg(nil), task.Legs...)
+	if leg, ok := task.Leg(models.LegEmpty); ok {
+		*leg = empty
+	} else {
+		task.Legs = append([]models.Leg{empty}, task.Legs...)
+	}
+	return true
+}
+
+// passengersEnd returns when the passengers of the task have left the bus,
+// that is the end of the task without its return leg.
+func passengersEnd(task models.Task) time.Time {
+	if leg, ok := task.Leg(models.LegReturn); ok {
+		return leg.PlannedStart
+	}
+	return task.TimeEnd
+}
+
+// freeAfter returns where and since when the bus is free for another task
+// after the task. A return leg the driver has not started is dropped when
+// the bus gets another task, so the bus is free at the drop-off. A started
+// one takes the bus to its parking first.
+func freeAfter(task models.Task) (string, time.Time, bool) {
+	if leg, ok := task.Leg(models.LegReturn); ok && leg.ActualStart != nil {
+		return leg.To, task.TimeEnd, true
+	}
+	vertex, ok := dropoffVertex(task)
+	return vertex, passengersEnd(task), ok
+}
+
+// withReturns gives the last unfinished task of every bus a return leg to its
+// parking and drops the return legs of the tasks before it, so that a bus is
+// only sent back when nothing is planned for it. The end of the task follows
+// its return leg. Tasks of buses without a state and return legs a driver
+// has started are left alone. It returns the indexes of the changed tasks.
+func withReturns(graph *distancegraph.Distancegraph, states []BusState, tasks []models.Task) []int {
+	parkings := make(map[int]string, len(states))
+	for _, state := range states {
+		parkings[state.Id] = state.Parking
+	}
+
+	last := make(map[int]int)
+	for i, task := range tasks {
+		if task.Status == models.TaskStatusComplete {
+			continue
+		}
+		if j, ok := last[task.BusID]; !ok || task.TimeStart.After(tasks[j].TimeStart) {
+			last[task.BusID] = i
+		}
+	}
+
+	var changed []int
+	for i := range tasks {
+		task := &tasks[i]
+		if task.Status == models.TaskStatusComplete {
+			continue
+		}
+
+		parking, known := parkings[task.BusID]
+		leg, hasReturn := task.Leg(models.LegReturn)
+		if !known || hasReturn && leg.ActualStart != nil {
+			continue
+		}
+
+		wantReturn := parking != "" && last[task.BusID] == i
+
+		switch {
+		case hasReturn && !wantReturn:
+			task.TimeEnd = leg.PlannedStart
+			legs := task.Legs[:0:0]
+			for _, l := range task.Legs {
+				if l.Kind != models.LegReturn {
+					legs = append(legs, l)
+				}
+			}
+			task.Legs = legs
+			changed = append(changed, i)
+		case !hasReturn && wantReturn && len(task.Legs) > 0:
+			from, _ := dropoffVertex(*task)
+			back, ok := driveLeg(graph, models.LegReturn, from, parking, task.TimeEnd)
+			if !ok {
+				continue
+			}
+			task.Legs = append(task.Legs, back)
+			task.TimeEnd = back.PlannedEnd
+			changed = append(changed, i)
+		}
+	}
+
+	return changed
+}
+
+// addReturns updates the return legs of the stored tasks with the diff
+// applied. Changed tasks are updated in the diff, stored tasks that were not
+// moved by it are added to the moved ones.
+func addReturns(graph *distancegraph.Distancegraph, states []BusState, stored []models.Task, diff *Diff) {
+	removed := make(map[int]bool, len(diff.Removed))
+	for _, task := range diff.Removed {
+		removed[task.Id] = true
+	}
+	moved := make(map[int]int, len(diff.Moved))
+	for i, task := range diff.Moved {
+		moved[task.Id] = i
+	}
+
+	var tasks []models.Task
+	for _, task := range stored {
+		switch i, ok := moved[task.Id]; {
+		case removed[task.Id]:
+		case ok:
+			tasks = append(tasks, diff.Moved[i])
+		default:
+			tasks = append(tasks, task)
+		}
+	}
+	firstAdded := len(tasks)
+	tasks = append(tasks, diff.Added...)
+
+	for _, i := range withReturns(graph, states, tasks) {
+		task := tasks[i]
+		if i >= firstAdded {
+			diff.Added[i-firstAdded] = task
+		} else if j, ok := moved[task.Id]; ok {
+			diff.Moved[j] = task
+		} else {
+			diff.Moved = append(diff.Moved, task)
+			diff.Kept--
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+func TestWithReturns(t *testing.T) {
+	graph := testGraph(t)
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	buses := states([]models.Bus{workingBus(1, 30)}, base)
+
+	task := func(id int, stand string, at time.Duration) models.Task {
+		flight := arrival(id, stand, base.Add(at), 20)
+		task, ok := newTask(graph, ServiceTimes{}, 1, flight, 20, "P", flight.Time().Add(-30*time.Minute), flight.Time())
+		if !ok {
+			t.Fatal("newTask() failed")
+		}
+		task.Id = id
+		return task
+	}
+
+	// A single task gets a return leg and ends at the parking.
+	tasks := []models.Task{task(1, "S1", time.Hour)}
+	unloaded := tasks[0].TimeEnd
+	if changed := withReturns(graph, buses, tasks); len(changed) != 1 {
+		t.Fatalf("withReturns() changed %v, want the task", changed)
+	}
+	back, ok := tasks[0].Leg(models.LegReturn)
+	if !ok {
+		t.Fatal("task has no return leg")
+	}
+	if back.From != "G" || back.To != "P" || !back.PlannedStart.Equal(unloaded) {
+		t.Errorf("return leg %s-%s at %s, want G-P at %s", back.From, back.To, back.PlannedStart, unloaded)
+	}
+	if !tasks[0].TimeEnd.Equal(back.PlannedEnd) {
+		t.Errorf("task ends at %s, want the end of the return leg %s", tasks[0].TimeEnd, back.PlannedEnd)
+	}
+	if vertex, free, _ := freeAfter(tasks[0]); vertex != "G" || !free.Equal(unloaded) {
+		t.Errorf("freeAfter() = %s at %s, want G at %s", vertex, free, unloaded)
+	}
+
+	// Another task after it takes the return leg over.
+	tasks = append(tasks, task(2, "S2", 2*time.Hour))
+	if changed := withReturns(graph, buses, tasks); len(changed) != 2 {
+		t.Fatalf("withReturns() changed %v, want both tasks", changed)
+	}
+	if _, ok := tasks[0].Leg(models.LegReturn); ok {
+		t.Error("earlier task kept its return leg")
+	}
+	if !tasks[0].TimeEnd.Equal(unloaded) {
+		t.Errorf("earlier task ends at %s, want %s", tasks[0].TimeEnd, unloaded)
+	}
+	if _, ok := tasks[1].Leg(models.LegReturn); !ok {
+		t.Error("last task has no return leg")
+	}
+
+	// A return leg the driver has started stays and keeps the bus busy.
+	tasks = []models.Task{task(3, "S1", time.Hour)}
+	withReturns(graph, buses, tasks)
+	leg, _ := tasks[0].Leg(models.LegReturn)
+	leg.ActualStart = &leg.PlannedStart
+	tasks = append(tasks, task(4, "S2", 2*time.Hour))
+	withReturns(graph, buses, tasks)
+	if _, ok := tasks[0].Leg(models.LegReturn); !ok {
+		t.Error("started return leg was dropped")
+	}
+	if vertex, free, _ := freeAfter(tasks[0]); vertex != "P" || !free.Equal(tasks[0].TimeEnd) {
+		t.Errorf("freeAfter() = %s at %s, want P at %s", vertex, free, tasks[0].TimeEnd)
+	}
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"sort"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+// Metrics describes the quality of a schedule.
+type Metrics struct {
+	Tasks             int     `json:"tasks"`
+	Passengers        int     `json:"passengers"`
+	CoveredPassengers int     `json:"covered_passengers"`
+	Coverage          float64 `json:"coverage"`
+	UncoveredFlights  int     `json:"uncovered_flights"`
+	LatePickups       int     `json:"late_pickups"`
+	EmptyDistance     float64 `json:"empty_distance_km"`
+}
+
+// Evaluate computes schedule metrics. A pickup is late if the bus, driving
+// from the drop-off of its previous task, reaches the pickup point after the
+// planned boarding.
+func Evaluate(tasks []models.Task, flights []models.Flight, buses []BusState, graph *distancegraph.Distancegraph) Metrics {
+	flightsByID := make(map[int]models.Flight, len(flights))
+	for _, flight := range flights {
+		flightsByID[flight.Id] = flight
+	}
+
+	locations := make(map[int]string, len(buses))
+	for _, bus := range buses {
+		locations[bus.Id] = bus.Location
+	}
+
+	sorted := make([]models.Task, len(tasks))
+	copy(sorted, tasks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].TimeStart.Before(sorted[j].TimeStart)
+	})
+
+	metrics := Metrics{Tasks: len(tasks)}
+	carried := make(map[int]int, len(flights))
+	for _, task := range sorted {
+		flight := flightsByID[task.FlightID]
+		travel, ok := graph.TravelTime(locations[task.BusID], flight.Pickup(), task.TimeStart)
+		if !ok || task.TimeStart.Add(travel).After(pickupTime(task)) {
+			metrics.LatePickups++
+		}
+		if ok {
+			metrics.EmptyDistance += graph.MinDistance(locations[task.BusID], flight.Pickup())
+		}
+
+		carried[flight.Id] += task.Passengers
+		locations[task.BusID] = flight.Dropoff()
+	}
+
+	for _, flight := range flights {
+		metrics.Passengers += flight.Passengers
+		if carried[flight.Id] < flight.Passengers {
+			metrics.CoveredPassengers += carried[flight.Id]
+			metrics.UncoveredFlights++
+		} else {
+			metrics.CoveredPassengers += flight.Passengers
+		}
+	}
+	if metrics.Passengers > 0 {
+		metrics.Coverage = float64(metrics.CoveredPassengers) / float64(metrics.Passengers)
+	}
+
+	return metrics
+}
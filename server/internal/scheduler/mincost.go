@@ -0,0 +1,260 @@
+package scheduler
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+// coverBonus is the negative cost of carrying one passenger. It is larger
+// than any possible empty run, so the solver first maximizes the number of
+// carried passengers and only then minimizes the empty distance.
+const coverBonus = 1 << 40
+
+// MinCostFlow solves the bus/trip assignment as a min-cost flow problem.
+//
+// Every flight is split into trips sized by the bus capacities. The network
+// is source -> bus -> trip -> trip -> ... -> sink, where an arc between two
+// nodes exists only if the bus can reach the pickup of the next trip in time
+// and costs the empty run in metres. Every unit of flow is the chain of trips of one bus.
+type MinCostFlow struct{}
+
+type trip struct {
+	flight     models.Flight
+	passengers int
+	start      time.Time
+	end        time.Time
+}
+
+type flowEdge struct {
+	to       int
+	rev      int
+	cap      int
+	capacity int
+	cost     int64
+}
+
+type flowNetwork struct {
+	edges [][]flowEdge
+}
+
+func (MinCostFlow) Schedule(flights []models.Flight, buses []BusState, graph *distancegraph.Distancegraph, times ServiceTimes) []models.Task {
+	// Buses of the same capacity form a class, classes are sorted
+	// from the largest to the smallest.
+	var classes [][]BusState
+	for _, bus := range byCapacity(buses) {
+		if bus.Status != models.BusStatusWork {
+			continue
+		}
+		last := len(classes) - 1
+		if last < 0 || capacity(classes[last][0]) != capacity(bus) {
+			classes = append(classes, nil)
+			last++
+		}
+		classes[last] = append(classes[last], bus)
+	}
+
+	var trips []trip
+	for _, flight := range flights {
+		// Flights that cannot be served are left unassigned.
+		pickup, legs, ok := tripLegs(graph, times, flight)
+		if !ok {
+			continue
+		}
+		trips = append(trips, trip{
+			flight:     flight,
+			passengers: flight.Passengers,
+			start:      pickup,
+			end:        legs[len(legs)-1].PlannedEnd,
+		})
+	}
+	sort.SliceStable(trips, func(i, j int) bool {
+		return trips[i].start.Before(trips[j].start)
+	})
+
+	// A chain of trips in the network is not bound to a bus, so every class
+	// is solved separately on the trips that are still uncovered. Trips are
+	// sized for the largest buses first, which gives the fewest trips, and
+	// what they could not take is split again for the next class.
+	var tasks []models.Task
+	for _, class := range classes {
+		var fitting []trip
+		for _, t := range trips {
+			fitting = append(fitting, splitTrip(t, capacity(class[0]))...)
+		}
+
+		classTasks, covered := solveMinCostFlow(class, fitting, graph, times)
+		tasks = append(tasks, classTasks...)
+
+		trips = trips[:0]
+		for i, t := range fitting {
+			if !covered[i] {
+				trips = append(trips, t)
+			}
+		}
+	}
+
+	return tasks
+}
+
+// solveMinCostFlow assigns trips to buses and reports which trips are covered.
+func solveMinCostFlow(buses []BusState, trips []trip, graph *distancegraph.Distancegraph, times ServiceTimes) ([]models.Task, []bool) {
+	// Node layout: source, sink, buses, trip inputs, trip outputs.
+	const source, sink = 0, 1
+	firstTripIn := 2 + len(buses)
+	firstTripOut := firstTripIn + len(trips)
+
+	network := newFlowNetwork(firstTripOut + len(trips))
+	for i, bus := range buses {
+		network.addEdge(source, 2+i, 1, 0)
+		network.addEdge(2+i, sink, 1, 0)
+
+		for j, t := range trips {
+			if cost, ok := emptyRunCost(graph, bus.Location, bus.FreeAt, t); ok {
+				network.addEdge(2+i, firstTripIn+j, 1, cost)
+			}
+		}
+	}
+
+	for i, from := range trips {
+		network.addEdge(firstTripIn+i, firstTripOut+i, 1, -coverBonus*int64(from.passengers))
+		network.addEdge(firstTripOut+i, sink, 1, 0)
+
+		for j, to := range trips {
+			if i == j {
+				continue
+			}
+			if cost, ok := emptyRunCost(graph, from.flight.Dropoff(), from.end, to); ok {
+				network.addEdge(firstTripOut+i, firstTripIn+j, 1, cost)
+			}
+		}
+	}
+
+	for network.augment(source, sink) {
+	}
+
+	var tasks []models.Task
+	covered := make([]bool, len(trips))
+	for i, bus := range buses {
+		location := bus.Location
+		free := bus.FreeAt
+		node := 2 + i
+		for {
+			next := network.flowTarget(node)
+			if next < firstTripIn || next >= firstTripOut {
+				break
+			}
+
+			t := trips[next-firstTripIn]
+
+			// The arc exists only if the bus makes the trip.
+			start, _ := departureTime(graph, location, t.flight.Pickup(), free, t.start)
+			task, ok := newTask(graph, times, bus.Id, t.flight, t.passengers, location, start, t.start)
+			if !ok {
+				break
+			}
+			tasks = append(tasks, task)
+			covered[next-firstTripIn] = true
+
+			location = t.flight.Dropoff()
+			free = t.end
+			node = firstTripOut + next - firstTripIn
+		}
+	}
+
+	return tasks, covered
+}
+
+// splitTrip splits a trip into the fewest trips that fit the capacity.
+func splitTrip(t trip, capacity int) []trip {
+	var trips []trip
+	for left := t.passengers; left > 0; left -= capacity {
+		part := t
+		if left < capacity {
+			part.passengers = left
+		} else {
+			part.passengers = capacity
+		}
+		trips = append(trips, part)
+	}
+	return trips
+}
+
+// emptyRunCost returns the empty run in metres for a bus that is free at
+// location from since time at, and false if the bus cannot make the trip.
+func emptyRunCost(graph *distancegraph.Distancegraph, from string, at time.Time, t trip) (int64, bool) {
+	if _, ok := departureTime(graph, from, t.flight.Pickup(), at, t.start); !ok {
+		return 0, false
+	}
+	return int64(math.Round(graph.MinDistance(from, t.flight.Pickup()) * 1000)), true
+}
+
+func newFlowNetwork(nodes int) *flowNetwork {
+	return &flowNetwork{edges: make([][]flowEdge, nodes)}
+}
+
+func (n *flowNetwork) addEdge(from, to, capacity int, cost int64) {
+	n.edges[from] = append(n.edges[from], flowEdge{to: to, rev: len(n.edges[to]), cap: capacity, capacity: capacity, cost: cost})
+	n.edges[to] = append(n.edges[to], flowEdge{to: from, rev: len(n.edges[from]) - 1, cost: -cost})
+}
+
+// flowTarget returns the node the flow leaving node goes to, or -1.
+func (n *flowNetwork) flowTarget(node int) int {
+	for _, e := range n.edges[node] {
+		if e.capacity > 0 && e.cap < e.capacity {
+			return e.to
+		}
+	}
+	return -1
+}
+
+// augment pushes one unit of flow along the cheapest path from source to sink
+// if that path has negative cost, i.e. if it improves the assignment.
+func (n *flowNetwork) augment(source, sink int) bool {
+	dist := make([]int64, len(n.edges))
+	prevNode := make([]int, len(n.edges))
+	prevEdge := make([]int, len(n.edges))
+	inQueue := make([]bool, len(n.edges))
+	for i := range dist {
+		dist[i] = math.MaxInt64
+		prevNode[i] = -1
+	}
+	dist[source] = 0
+
+	// SPFA: the residual network has negative arcs but no negative cycles.
+	queue := []int{source}
+	inQueue[source] = true
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		inQueue[node] = false
+
+		for i, e := range n.edges[node] {
+			if e.cap == 0 || dist[node]+e.cost >= dist[e.to] {
+				continue
+			}
+			dist[e.to] = dist[node] + e.cost
+			prevNode[e.to] = node
+			prevEdge[e.to] = i
+			if !inQueue[e.to] {
+				queue = append(queue, e.to)
+				inQueue[e.to] = true
+			}
+		}
+	}
+
+	if prevNode[sink] == -1 || dist[sink] >= 0 {
+		return false
+	}
+
+	for node := sink; node != source; node = prevNode[node] {
+		e := &n.edges[prevNode[node]][prevEdge[node]]
+		e.cap--
+		n.edges[node][e.rev].cap++
+	}
+
+	return true
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// Reassignment is the result of moving tasks away from a bus.
+type Reassignment struct {
+	Reassigned []models.Task `json:"reassigned"`
+	Unassigned []models.Task `json:"unassigned"`
+}
+
+// ReassignBusTasks moves the tasks of the bus that have one of the statuses
+// and overlap the time window to other working buses. Every task goes to the
+// bus with the shortest empty run that can fit it between its own tasks.
+// Tasks no bus can take stay where they are and are reported as unassigned,
+// as are the tasks changed by someone else while they were being moved.
+// A zero end of the window takes all tasks after its start.
+func (s *scheduler) ReassignBusTasks(busID int, from time.Time, to time.Time, statuses ...string) (Reassignment, error) {
+	const op = "lib.scheduler.ReassignBusTasks"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result Reassignment
+
+	tasks, err := s.taskStorage.GetTasks()
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	buses, err := s.busGetter.GetBuses()
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var working []models.Bus
+	for _, bus := range buses {
+		if bus.Id != busID && bus.Status == models.BusStatusWork {
+			working = append(working, bus)
+		}
+	}
+
+	states, err := s.busStates(working, time.Now())
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var moving, others []models.Task
+	for _, task := range tasks {
+		if task.BusID == busID {
+			if hasStatus(task, statuses) && (to.IsZero() || task.TimeStart.Before(to)) && task.TimeEnd.After(from) {
+				moving = append(moving, task)
+			}
+		} else {
+			others = append(others, task)
+		}
+	}
+	sortByStart(moving)
+
+	graph := s.distancegraph.Graph()
+	table := newTimetable(graph, states, others)
+	for _, task := range moving {
+		destination, ok := pickupVertex(task)
+		if !ok {
+			result.Unassigned = append(result.Unassigned, task)
+			continue
+		}
+
+		assigned, ok := table.assign(task, destination, 0)
+		if !ok {
+			result.Unassigned = append(result.Unassigned, task)
+			continue
+		}
+		result.Reassigned = append(result.Reassigned, assigned)
+	}
+
+	diff := Diff{Moved: table.moved()}
+	addReturns(graph, states, tasks, &diff)
+
+	err = s.write(&diff)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(diff.Skipped) > 0 {
+		skipped := make(map[int]bool, len(diff.Skipped))
+		for _, task := range diff.Skipped {
+			skipped[task.Id] = true
+		}
+		// The skipped tasks are reported as they were read, on the bus.
+		var reassigned []models.Task
+		for _, task := range result.Reassigned {
+			if !skipped[task.Id] {
+				reassigned = append(reassigned, task)
+			}
+		}
+		for _, task := range moving {
+			if skipped[task.Id] {
+				result.Unassigned = append(result.Unassigned, task)
+			}
+		}
+		result.Reassigned = reassigned
+	}
+
+	return result, nil
+}
+
+func hasStatus(task models.Task, statuses []string) bool {
+	for _, status := range statuses {
+		if task.Status == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/memory"
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/memory"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/memory"
+)
+
+// racingTasks changes a task, as a driver would, between the scheduler
+// reading the tasks and writing its diff.
+type racingTasks struct {
+	*taskstorage.TaskStorage
+	change func(task *models.Task)
+	taskID int
+}
+
+func (s racingTasks) ApplyDiff(removed []models.Task, moved []models.Task, added []models.Task) ([]int, error) {
+	tasks, _ := s.GetTasks()
+	for _, task := range tasks {
+		if task.Id == s.taskID {
+			s.change(&task)
+			if _, err := s.TaskStorage.ApplyDiff(nil, []models.Task{task}, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.TaskStorage.ApplyDiff(removed, moved, added)
+}
+
+func TestReassignSkipsChangedTasks(t *testing.T) {
+	graph := testGraph(t)
+	now := time.Now()
+
+	first := arrival(1, "S1", now.Add(time.Hour), 20)
+	second := arrival(2, "S2", now.Add(2*time.Hour), 20)
+	var stored []models.Task
+	for _, flight := range []models.Flight{first, second} {
+		task, ok := newTask(graph, ServiceTimes{}, 1, flight, 20, "P", flight.Time().Add(-30*time.Minute), flight.Time())
+		if !ok {
+			t.Fatal("newTask() failed")
+		}
+		stored = append(stored, task)
+	}
+
+	tasks := taskstorage.New()
+	if err := tasks.AddTasks(stored); err != nil {
+		t.Fatal(err)
+	}
+	storages := Storages{
+		Flights: flightstorage.New([]models.Flight{first, second}),
+		Buses:   busstorage.New([]models.Bus{workingBus(1, 30), workingBus(2, 30)}),
+		Tasks: racingTasks{
+			TaskStorage: tasks,
+			taskID:      1,
+			change:      func(task *models.Task) { task.Passengers = 10 },
+		},
+	}
+	s := NewWithStorages(storages, staticGraph{graph}, Greedy{}, ServiceTimes{}, 3*time.Hour)
+
+	result, err := s.ReassignBusTasks(1, now, time.Time{}, models.TaskStatusQueue)
+	if err != nil {
+		t.Fatalf("ReassignBusTasks() error = %v", err)
+	}
+
+	if len(result.Reassigned) != 1 || result.Reassigned[0].Id != 2 {
+		t.Errorf("reassigned = %+v, want task 2", result.Reassigned)
+	}
+	if len(result.Unassigned) != 1 || result.Unassigned[0].Id != 1 || result.Unassigned[0].BusID != 1 {
+		t.Errorf("unassigned = %+v, want task 1 of bus 1", result.Unassigned)
+	}
+
+	after, _ := tasks.GetTasks()
+	for _, task := range after {
+		switch task.Id {
+		case 1:
+			if task.BusID != 1 || task.Passengers != 10 {
+				t.Errorf("changed task = bus %d, %d passengers, want bus 1, 10 passengers", task.BusID, task.Passengers)
+			}
+		case 2:
+			if task.BusID != 2 {
+				t.Errorf("task 2 is on bus %d, want 2", task.BusID)
+			}
+		}
+	}
+}
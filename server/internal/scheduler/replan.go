@@ -0,0 +1,489 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+// minImprovement is the empty run in km a new plan has to save to be worth
+// moving already queued tasks.
+const minImprovement = 0.1
+
+// Diff describes how a planning cycle changes the stored tasks. Skipped are
+// the tasks to move or remove that were changed by someone else meanwhile
+// and so were left as they are.
+type Diff struct {
+	Added   []models.Task `json:"added"`
+	Moved   []models.Task `json:"moved"`
+	Removed []models.Task `json:"removed"`
+	Skipped []models.Task `json:"skipped,omitempty"`
+	Kept    int           `json:"kept"`
+}
+
+// Plan brings the stored tasks in line with the upcoming flights.
+//
+// Tasks in work, on pause or complete are kept as they are. Only the
+// passengers they do not carry are planned. Queued tasks of the upcoming
+// flights are replanned together with the uncovered passengers, and the
+// new plan replaces them only if it carries more passengers, has fewer late
+// pickups or a shorter empty run. Otherwise the queued tasks stay and the
+// uncovered passengers are planned after them.
+//
+// The upcoming flights are those whose passengers board within the time
+// interval of the scheduler, see upcomingFlights.
+//
+// With dryRun the diff is computed but not written.
+func (s *scheduler) Plan(dryRun bool) (Diff, error) {
+	const op = "lib.scheduler.Plan"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var diff Diff
+
+	flights, err := s.upcomingFlights(time.Now())
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	buses, err := s.busGetter.GetBuses()
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tasks, err := s.taskStorage.GetTasks()
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	states, err := s.busStates(buses, now)
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	graph := s.distancegraph.Graph()
+	upcoming := make(map[int]bool, len(flights))
+	for _, flight := range flights {
+		upcoming[flight.Id] = true
+	}
+	flights = servable(graph, active(flights))
+
+	// Queued tasks of the upcoming flights may be moved, those of cancelled
+	// or unservable flights are removed, everything else is fixed.
+	var fixed, movable, stale []models.Task
+	for _, task := range tasks {
+		switch {
+		case task.Status != models.TaskStatusQueue || !upcoming[task.FlightID]:
+			fixed = append(fixed, task)
+		case hasFlight(flights, task.FlightID):
+			movable = append(movable, task)
+		default:
+			stale = append(stale, task)
+		}
+	}
+
+	residual := uncovered(flights, fixed)
+	afterFixed := occupy(states, fixed)
+
+	// Keep the queued tasks and plan what they leave uncovered.
+	kept := append(append([]models.Task{}, movable...),
+		s.strategy.Schedule(uncovered(residual, movable), occupy(afterFixed, movable), graph, s.serviceTimes)...)
+
+	// Plan the queued passengers again from scratch.
+	replanned := s.strategy.Schedule(residual, afterFixed, graph, s.serviceTimes)
+
+	if better(Evaluate(replanned, residual, afterFixed, graph), Evaluate(kept, residual, afterFixed, graph)) {
+		diff = diffTasks(movable, replanned)
+	} else {
+		diff = Diff{Added: kept[len(movable):], Kept: len(movable)}
+	}
+	diff.Kept += len(fixed)
+	diff.Removed = append(diff.Removed, stale...)
+	addReturns(graph, states, tasks, &diff)
+
+	if dryRun {
+		return diff, nil
+	}
+
+	err = s.write(&diff)
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return diff, nil
+}
+
+// ReplanFlights updates the queued tasks of the changed flights without
+// touching the rest of the plan. The flights are loaded by id, so a flight
+// moved out of the planning window keeps its tasks. Tasks of cancelled,
+// deleted or unservable flights are removed. The other tasks follow the new
+// time, stand and gate of their flight, staying on their bus if it still
+// makes it, and the passengers they do not carry any more are fitted into
+// the gaps of the working buses. A task no bus can drive in time any more
+// is kept as it is for the dispatcher to decide.
+func (s *scheduler) ReplanFlights(flightIDs []int) (Diff, error) {
+	const op = "lib.scheduler.ReplanFlights"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var diff Diff
+
+	var flights []models.Flight
+	for _, id := range flightIDs {
+		flight, err := s.flightGetter.GetFlight(id)
+		if errors.Is(err, flightstorage.ErrFlightNotFound) {
+			continue
+		}
+		if err != nil {
+			return diff, fmt.Errorf("%s: %w", op, err)
+		}
+		flights = append(flights, flight)
+	}
+
+	buses, err := s.busGetter.GetBuses()
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tasks, err := s.taskStorage.GetTasks()
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	states, err := s.busStates(buses, time.Now())
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	changed := make(map[int]bool, len(flightIDs))
+	for _, id := range flightIDs {
+		changed[id] = true
+	}
+
+	var affected, fixed []models.Task
+	for _, task := range tasks {
+		if task.Status == models.TaskStatusQueue && changed[task.FlightID] {
+			affected = append(affected, task)
+		} else {
+			fixed = append(fixed, task)
+		}
+	}
+	sortByStart(affected)
+
+	graph := s.distancegraph.Graph()
+	targets := servable(graph, active(flights))
+	left := make(map[int]int, len(targets))
+	for _, flight := range uncovered(targets, fixed) {
+		left[flight.Id] = flight.Passengers
+	}
+
+	table := newTimetable(graph, states, fixed)
+	for _, stored := range affected {
+		task := stored
+		flight, ok := findFlight(targets, task.FlightID)
+		if !ok || left[flight.Id] <= 0 {
+			diff.Removed = append(diff.Removed, task)
+			table.release(task)
+			continue
+		}
+
+		if task.Passengers > left[flight.Id] {
+			task.Passengers = left[flight.Id]
+		}
+		_, legs, ok := tripLegs(graph, s.serviceTimes, flight)
+		if !ok {
+			diff.Removed = append(diff.Removed, task)
+			table.release(task)
+			continue
+		}
+		task.Legs = legs
+		task.TimeEnd = legs[len(legs)-1].PlannedEnd
+		task.To = flight.Dropoff()
+
+		assigned, ok := table.assign(task, flight.Pickup(), task.BusID)
+		if !ok {
+			// No bus makes it in time, the dispatcher has to decide.
+			table.keep(stored)
+			left[flight.Id] -= stored.Passengers
+			continue
+		}
+		left[flight.Id] -= assigned.Passengers
+	}
+
+	for _, flight := range targets {
+		_, legs, ok := tripLegs(graph, s.serviceTimes, flight)
+		for ok && left[flight.Id] > 0 {
+			trip := models.Task{
+				FlightID:   flight.Id,
+				Passengers: left[flight.Id],
+				TimeEnd:    legs[len(legs)-1].PlannedEnd,
+				To:         flight.Dropoff(),
+				Legs:       legs,
+			}
+			if !assignTrip(table, &trip, flight.Pickup()) {
+				break
+			}
+			left[flight.Id] -= trip.Passengers
+		}
+	}
+
+	diff.Added = table.added()
+	diff.Moved = table.moved()
+	diff.Kept = len(tasks) - len(diff.Moved) - len(diff.Removed)
+	addReturns(graph, states, tasks, &diff)
+
+	err = s.write(&diff)
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return diff, nil
+}
+
+// assignTrip fits the trip to a bus, the largest first. The passengers of
+// the trip are cut down to the capacity of the bus.
+func assignTrip(table *timetable, trip *models.Task, destination string) bool {
+	for _, state := range byCapacity(table.states) {
+		part := *trip
+		if part.Passengers > capacity(state) {
+			part.Passengers = capacity(state)
+		}
+		if assigned, ok := table.assign(part, destination, 0); ok {
+			*trip = assigned
+			return true
+		}
+	}
+	return false
+}
+
+// write applies the diff to the task storage in one transaction. The tasks
+// the storage skipped are moved from the removed and the moved ones of the
+// diff to the skipped ones.
+func (s *scheduler) write(diff *Diff) error {
+	const op = "lib.scheduler.write"
+
+	skipped, err := s.taskStorage.ApplyDiff(diff.Removed, diff.Moved, diff.Added)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(skipped) == 0 {
+		return nil
+	}
+
+	ids := make(map[int]bool, len(skipped))
+	for _, id := range skipped {
+		ids[id] = true
+	}
+	split := func(tasks []models.Task) []models.Task {
+		var written []models.Task
+		for _, task := range tasks {
+			if ids[task.Id] {
+				diff.Skipped = append(diff.Skipped, task)
+			} else {
+				written = append(written, task)
+			}
+		}
+		return written
+	}
+	diff.Removed = split(diff.Removed)
+	diff.Moved = split(diff.Moved)
+
+	return nil
+}
+
+// upcomingFlights returns the flights whose passengers board a bus before
+// the end of the time interval from now. Passengers of a departure board
+// long before the flight leaves, so the flights are read further ahead and
+// then filtered by their pickup. A departure whose stand cannot be reached
+// is taken by the time of the flight.
+func (s *scheduler) upcomingFlights(now time.Time) ([]models.Flight, error) {
+	const op = "lib.scheduler.upcomingFlights"
+
+	lookahead := departureLead + s.serviceTimes.Longest() + longestDrive
+	flights, err := s.flightGetter.GetFlights(s.timeInterval + lookahead)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	graph := s.distancegraph.Graph()
+	horizon := now.Add(s.timeInterval)
+	var upcoming []models.Flight
+	for _, flight := range flights {
+		pickup, ok := pickupAt(graph, s.serviceTimes, flight)
+		if !ok {
+			pickup = flight.Time()
+		}
+		if !pickup.After(horizon) {
+			upcoming = append(upcoming, flight)
+		}
+	}
+	return upcoming, nil
+}
+
+// active drops cancelled flights.
+func active(flights []models.Flight) []models.Flight {
+	var left []models.Flight
+	for _, flight := range flights {
+		if flight.Status != models.FlightStatusCancelled {
+			left = append(left, flight)
+		}
+	}
+	return left
+}
+
+// servable drops flights whose pickup or drop-off point is not a vertex of
+// the graph, such as flights stored without a gate. They stay unplanned
+// until the flight is fixed.
+func servable(graph *distancegraph.Distancegraph, flights []models.Flight) []models.Flight {
+	var left []models.Flight
+	for _, flight := range flights {
+		if graph.HasVertex(flight.Pickup()) && graph.HasVertex(flight.Dropoff()) {
+			left = append(left, flight)
+		}
+	}
+	return left
+}
+
+func findFlight(flights []models.Flight, id int) (models.Flight, bool) {
+	for _, flight := range flights {
+		if flight.Id == id {
+			return flight, true
+		}
+	}
+	return models.Flight{}, false
+}
+
+func hasFlight(flights []models.Flight, id int) bool {
+	_, ok := findFlight(flights, id)
+	return ok
+}
+
+// uncovered returns the flights with the passengers the tasks do not carry.
+// Flights without such passengers are dropped.
+func uncovered(flights []models.Flight, tasks []models.Task) []models.Flight {
+	carried := make(map[int]int)
+	for _, task := range tasks {
+		carried[task.FlightID] += task.Passengers
+	}
+
+	var left []models.Flight
+	for _, flight := range flights {
+		if flight.Passengers > carried[flight.Id] {
+			flight.Passengers -= carried[flight.Id]
+			left = append(left, flight)
+		}
+	}
+	return left
+}
+
+// occupy moves every bus to the end of its last unfinished task.
+func occupy(states []BusState, tasks []models.Task) []BusState {
+	occupied := make([]BusState, len(states))
+	copy(occupied, states)
+
+	index := make(map[int]int, len(occupied))
+	for i, state := range occupied {
+		index[state.Id] = i
+	}
+
+	for _, task := range tasks {
+		i, ok := index[task.BusID]
+		if !ok || task.Status == models.TaskStatusComplete {
+			continue
+		}
+		vertex, end, ok := freeAfter(task)
+		if !end.After(occupied[i].FreeAt) {
+			continue
+		}
+		occupied[i].FreeAt = end
+		if ok {
+			occupied[i].Location = vertex
+		}
+	}
+
+	return occupied
+}
+
+// better reports whether the plan with metrics a beats the plan with metrics b.
+func better(a, b Metrics) bool {
+	if a.CoveredPassengers != b.CoveredPassengers {
+		return a.CoveredPassengers > b.CoveredPassengers
+	}
+	if a.LatePickups != b.LatePickups {
+		return a.LatePickups < b.LatePickups
+	}
+	return a.EmptyDistance < b.EmptyDistance-minImprovement
+}
+
+// diffTasks turns replacing the old tasks with the new ones into a diff.
+// A new task reuses an old task of the same flight, preferably of the
+// same bus, so that moved tasks keep their ids.
+func diffTasks(old []models.Task, planned []models.Task) Diff {
+	var diff Diff
+
+	used := make([]bool, len(old))
+	match := func(task models.Task, sameBus bool) int {
+		for i, o := range old {
+			if !used[i] && o.FlightID == task.FlightID && (!sameBus || o.BusID == task.BusID) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var rest []models.Task
+	for _, task := range planned {
+		i := match(task, true)
+		if i == -1 {
+			rest = append(rest, task)
+			continue
+		}
+		used[i] = true
+		task.Id = old[i].Id
+		if unchanged(old[i], task) {
+			diff.Kept++
+		} else {
+			diff.Moved = append(diff.Moved, task)
+		}
+	}
+
+	for _, task := range rest {
+		i := match(task, false)
+		if i == -1 {
+			diff.Added = append(diff.Added, task)
+			continue
+		}
+		used[i] = true
+		task.Id = old[i].Id
+		diff.Moved = append(diff.Moved, task)
+	}
+
+	for i, task := range old {
+		if !used[i] {
+			diff.Removed = append(diff.Removed, task)
+		}
+	}
+
+	return diff
+}
+
+func unchanged(a, b models.Task) bool {
+	// Return legs are not planned by the strategies, they are added after.
+	if a.BusID != b.BusID || a.Passengers != b.Passengers || a.From != b.From || a.To != b.To ||
+		!a.TimeStart.Equal(b.TimeStart) || !passengersEnd(a).Equal(passengersEnd(b)) || len(a.Route) != len(b.Route) {
+		return false
+	}
+	for i := range a.Route {
+		if a.Route[i] != b.Route[i] {
+			return false
+		}
+	}
+	return true
+}
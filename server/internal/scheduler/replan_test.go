@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+func TestDiffTasks(t *testing.T) {
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	task := func(id, busID, flightID int, start time.Duration) models.Task {
+		return models.Task{
+			Id:         id,
+			BusID:      busID,
+			FlightID:   flightID,
+			Passengers: 20,
+			TimeStart:  base.Add(start),
+			TimeEnd:    base.Add(start + 30*time.Minute),
+			From:       "S1",
+			To:         "G",
+		}
+	}
+
+	tests := []struct {
+		name        string
+		old         []models.Task
+		planned     []models.Task
+		wantKept    int
+		wantMoved   []int // ids
+		wantAdded   int
+		wantRemoved []int // ids
+	}{
+		{
+			name:     "same plan",
+			old:      []models.Task{task(1, 1, 10, 0), task(2, 2, 11, 0)},
+			planned:  []models.Task{task(0, 1, 10, 0), task(0, 2, 11, 0)},
+			wantKept: 2,
+		},
+		{
+			name:      "new start time",
+			old:       []models.Task{task(1, 1, 10, 0)},
+			planned:   []models.Task{task(0, 1, 10, 5*time.Minute)},
+			wantMoved: []int{1},
+		},
+		{
+			name:      "another bus",
+			old:       []models.Task{task(1, 1, 10, 0)},
+			planned:   []models.Task{task(0, 2, 10, 0)},
+			wantMoved: []int{1},
+		},
+		{
+			name:     "same bus is preferred",
+			old:      []models.Task{task(1, 1, 10, 0), task(2, 2, 10, 0)},
+			planned:  []models.Task{task(0, 2, 10, 0), task(0, 1, 10, 0)},
+			wantKept: 2,
+		},
+		{
+			name:        "flight no longer planned",
+			old:         []models.Task{task(1, 1, 10, 0), task(2, 1, 11, time.Hour)},
+			planned:     []models.Task{task(0, 1, 10, 0)},
+			wantKept:    1,
+			wantRemoved: []int{2},
+		},
+		{
+			name:      "extra trip",
+			old:       []models.Task{task(1, 1, 10, 0)},
+			planned:   []models.Task{task(0, 1, 10, 0), task(0, 2, 10, 0)},
+			wantKept:  1,
+			wantAdded: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffTasks(tt.old, tt.planned)
+
+			if diff.Kept != tt.wantKept {
+				t.Errorf("kept = %d, want %d", diff.Kept, tt.wantKept)
+			}
+			if len(diff.Added) != tt.wantAdded {
+				t.Errorf("added = %d, want %d", len(diff.Added), tt.wantAdded)
+			}
+			for _, task := range diff.Added {
+				if task.Id != 0 {
+					t.Errorf("added task has id %d", task.Id)
+				}
+			}
+			if got := ids(diff.Moved); !sameIDs(got, tt.wantMoved) {
+				t.Errorf("moved = %v, want %v", got, tt.wantMoved)
+			}
+			if got := ids(diff.Removed); !sameIDs(got, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", got, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func ids(tasks []models.Task) []int {
+	var result []int
+	for _, task := range tasks {
+		result = append(result, task.Id)
+	}
+	return result
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[int]int)
+	for _, id := range a {
+		count[id]++
+	}
+	for _, id := range b {
+		count[id]--
+	}
+	for _, n := range count {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -2,11 +2,13 @@ package scheduler
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/config"
 	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	locationstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/location-storage/postgresql"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
 	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
 	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
@@ -14,25 +16,50 @@ import (
 
 type FlightGetter interface {
 	GetFlights(timeInterval time.Duration) ([]models.Flight, error)
+	GetFlight(flightID int) (models.Flight, error)
 }
 
 type BusGetter interface {
 	GetBuses() ([]models.Bus, error)
 }
 
-type TasksAdder interface {
-	AddTasks(tasks []models.Task) error
+type TaskStorage interface {
+	GetTasks() ([]models.Task, error)
+	ApplyDiff(removed []models.Task, moved []models.Task, added []models.Task) ([]int, error)
+}
+
+type LocationGetter interface {
+	GetLastLocations() (map[int]models.Location, error)
+}
+
+// Storages are the data sources of the scheduler. Locations may be nil,
+// then buses start from their parking.
+type Storages struct {
+	Flights   FlightGetter
+	Buses     BusGetter
+	Tasks     TaskStorage
+	Locations LocationGetter
+}
+
+// GraphProvider returns the current distance graph. The graph may be
+// replaced at runtime, so it is requested once per scheduling cycle.
+type GraphProvider interface {
+	Graph() *distancegraph.Distancegraph
 }
 
 type scheduler struct {
-	flightGetter FlightGetter
-	busGetter    BusGetter
-	tasksAdder   TasksAdder
-	timeInterval time.Duration
-	distancegraph *distancegraph.Distancegraph
+	mu             sync.Mutex // serializes changes of the schedule
+	flightGetter   FlightGetter
+	busGetter      BusGetter
+	taskStorage    TaskStorage
+	locationGetter LocationGetter
+	timeInterval   time.Duration
+	distancegraph  GraphProvider
+	strategy       Strategy
+	serviceTimes   ServiceTimes
 }
 
-func New(cfg *config.Config, timeInterval time.Duration) (*scheduler, error) {
+func New(cfg *config.Config, graph GraphProvider, timeInterval time.Duration) (*scheduler, error) {
 	const op = "lib.scheduler.New"
 
 	flightGetter, err := flightstorage.New(cfg.FS.Host, cfg.FS.Port, cfg.FS.User, cfg.FS.Password, cfg.FS.DBname)
@@ -43,41 +70,49 @@ func New(cfg *config.Config, timeInterval time.Duration) (*scheduler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	tasksAdder, err := taskstorage.New(cfg.TS.Host, cfg.TS.Port, cfg.TS.User, cfg.TS.Password, cfg.TS.DBname)
+	taskStorage, err := taskstorage.New(cfg.TS.Host, cfg.TS.Port, cfg.TS.User, cfg.TS.Password, cfg.TS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	locationGetter, err := locationstorage.New(cfg.LS.Host, cfg.LS.Port, cfg.LS.User, cfg.LS.Password, cfg.LS.DBname)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	graph, err := distancegraph.New()
+	strategy, err := NewStrategy(cfg.Scheduler.Strategy)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	storages := Storages{
+		Flights:   flightGetter,
+		Buses:     busGetter,
+		Tasks:     taskStorage,
+		Locations: locationGetter,
+	}
+
+	return NewWithStorages(storages, graph, strategy, NewServiceTimes(cfg.Scheduler.ServiceTimes), timeInterval), nil
+}
+
+// NewWithStorages creates a scheduler on top of already opened storages.
+func NewWithStorages(storages Storages, graph GraphProvider, strategy Strategy, serviceTimes ServiceTimes, timeInterval time.Duration) *scheduler {
 	return &scheduler{
-		flightGetter: flightGetter,
-		busGetter:    busGetter,
-		tasksAdder:   tasksAdder,
-		timeInterval: timeInterval,
-		distancegraph: graph,
-	}, nil
+		flightGetter:   storages.Flights,
+		busGetter:      storages.Buses,
+		taskStorage:    storages.Tasks,
+		locationGetter: storages.Locations,
+		timeInterval:   timeInterval,
+		distancegraph:  graph,
+		strategy:       strategy,
+		serviceTimes:   serviceTimes,
+	}
 }
 
+// Create runs a planning cycle and writes its result.
 func (s *scheduler) Create() error {
 	const op = "lib.scheduler.Create"
 
-	flights, err := s.flightGetter.GetFlights(s.timeInterval)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	buses, err := s.busGetter.GetBuses()
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	tasks := generateSchedule(flights, buses)
-
-	err = s.tasksAdder.AddTasks(tasks)
+	_, err := s.Plan(false)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -85,22 +120,28 @@ func (s *scheduler) Create() error {
 	return nil
 }
 
+// busStates starts every bus from its last known location,
+// or from its parking if the location is unknown.
+func (s *scheduler) busStates(buses []models.Bus, now time.Time) ([]BusState, error) {
+	const op = "lib.scheduler.busStates"
+
+	locations := map[int]models.Location{}
+	if s.locationGetter != nil {
+		var err error
+		locations, err = s.locationGetter.GetLastLocations()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
 
-func generateSchedule(flights []models.Flight, buses []models.Bus) []models.Task {
-	const busCapacity = 30
-	const busSpeed = 45 // km/h
-	
-	// TODO: finish algorithm
-
-	// Идея алгоритма:
+	states := make([]BusState, 0, len(buses))
+	for _, bus := range buses {
+		state := BusState{Bus: bus, Location: bus.Parking, FreeAt: now}
+		if location, ok := locations[bus.Id]; ok && location.Vertex != "" {
+			state.Location = location.Vertex
+		}
+		states = append(states, state)
+	}
 
-	// Цикл по всем активным автобусам: 
-	//     Создаем переменные: список задач, время последней задачи автобуса, локация последней задачи автобуса
-	//     Находим ближайший по времени рейс, на который успевает автобус (используем граф для подсчета расстояния и времени)
-	//     Добавляем задачу автобусу, меняем его время и позицию.
-	//     Из количества пассажиров рейса вычитаем количество пассажиров автобусов
-	//	   Если пассажиров больше не осталось, больше не учитываем этот рейс
-	//     Если нет задачи, удовлетворяющей автобусу, то переходим с следующему
-	// Сложность алгоритма: O(n*m), где n - число автобусов, m - число рейсов
-	return nil
+	return states, nil
 }
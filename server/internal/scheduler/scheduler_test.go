@@ -0,0 +1,421 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/memory"
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/memory"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/memory"
+)
+
+// staticGraph provides the same graph to every cycle.
+type staticGraph struct {
+	graph *distancegraph.Distancegraph
+}
+
+func (g staticGraph) Graph() *distancegraph.Distancegraph {
+	return g.graph
+}
+
+// testGraph is a small airport where a bus drives 1 km in 80 seconds:
+//
+//	P --6 min-- S1 --4 min-- G --4 min-- S2
+//	 \----------8 min-------/
+//
+// P is the parking, S1 and S2 are stands and G is the gate.
+func testGraph(t *testing.T) *distancegraph.Distancegraph {
+	t.Helper()
+
+	vertices := []distancegraph.Vertex{
+		{Name: "P", Type: distancegraph.VertexParking},
+		{Name: "S1", Type: distancegraph.VertexStand},
+		{Name: "S2", Type: distancegraph.VertexStand},
+		{Name: "G", Type: distancegraph.VertexGate},
+	}
+	var paths []distancegraph.Path
+	for _, e := range []distancegraph.Path{
+		{From: "P", To: "S1", Length: 4.5},
+		{From: "S1", To: "G", Length: 3},
+		{From: "G", To: "S2", Length: 3},
+		{From: "P", To: "G", Length: 6},
+	} {
+		paths = append(paths, e, distancegraph.Path{From: e.To, To: e.From, Length: e.Length})
+	}
+
+	graph, err := distancegraph.Build(vertices, paths, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	return graph
+}
+
+// arrival is a flight landing at the stand at the time.
+func arrival(id int, stand string, at time.Time, passengers int) models.Flight {
+	return models.Flight{
+		Id:            id,
+		Number:        "SU" + string(rune('0'+id)),
+		Direction:     models.FlightArrival,
+		Stand:         stand,
+		Gate:          "G",
+		ScheduledTime: at,
+		Status:        models.FlightStatusScheduled,
+		Passengers:    passengers,
+	}
+}
+
+func workingBus(id int, capacity int) models.Bus {
+	return models.Bus{Id: id, Status: models.BusStatusWork, Parking: "P", Capacity: capacity}
+}
+
+func states(buses []models.Bus, at time.Time) []BusState {
+	var result []BusState
+	for _, bus := range buses {
+		result = append(result, BusState{Bus: bus, Location: bus.Parking, FreeAt: at})
+	}
+	return result
+}
+
+// checkSchedule verifies that every bus can drive its tasks one after another.
+func checkSchedule(t *testing.T, graph *distancegraph.Distancegraph, tasks []models.Task, buses []BusState) {
+	t.Helper()
+
+	byBus := make(map[int][]models.Task)
+	for _, task := range tasks {
+		byBus[task.BusID] = append(byBus[task.BusID], task)
+	}
+
+	for _, bus := range buses {
+		schedule := byBus[bus.Id]
+		sortByStart(schedule)
+
+		location, free := bus.Location, bus.FreeAt
+		for _, task := range schedule {
+			if task.Passengers > capacity(bus) {
+				t.Errorf("bus %d carries %d passengers, capacity %d", bus.Id, task.Passengers, capacity(bus))
+			}
+			if task.TimeStart.Before(free) {
+				t.Errorf("task of flight %d starts at %s, bus %d is free at %s", task.FlightID, task.TimeStart, bus.Id, free)
+			}
+			travel, ok := graph.TravelTime(location, task.From, task.TimeStart)
+			if !ok || task.TimeStart.Add(travel).After(pickupTime(task)) {
+				t.Errorf("bus %d is late for the pickup of flight %d", bus.Id, task.FlightID)
+			}
+			location, free = task.To, task.TimeEnd
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	graph := testGraph(t)
+	now := time.Now()
+
+	flights := flightstorage.New([]models.Flight{
+		arrival(1, "S1", now.Add(time.Hour), 50),
+		arrival(2, "S2", now.Add(2*time.Hour), 20),
+	})
+	tasks := taskstorage.New()
+	storages := Storages{
+		Flights: flights,
+		Buses:   busstorage.New([]models.Bus{workingBus(1, 30), workingBus(2, 30)}),
+		Tasks:   tasks,
+	}
+	s := NewWithStorages(storages, staticGraph{graph}, Greedy{}, ServiceTimes{}, 3*time.Hour)
+
+	diff, err := s.Plan(true)
+	if err != nil {
+		t.Fatalf("Plan(dry run) error = %v", err)
+	}
+	if len(diff.Added) == 0 {
+		t.Fatal("Plan(dry run) added no tasks")
+	}
+	if stored, _ := tasks.GetTasks(); len(stored) != 0 {
+		t.Fatalf("Plan(dry run) stored %d tasks", len(stored))
+	}
+
+	diff, err = s.Plan(false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	stored, _ := tasks.GetTasks()
+	if len(stored) != len(diff.Added) {
+		t.Fatalf("stored %d tasks, diff added %d", len(stored), len(diff.Added))
+	}
+	carried := make(map[int]int)
+	for _, task := range stored {
+		carried[task.FlightID] += task.Passengers
+	}
+	if carried[1] != 50 || carried[2] != 20 {
+		t.Errorf("carried passengers = %v, want 50 of flight 1 and 20 of flight 2", carried)
+	}
+
+	// Nothing changed, so the next cycle keeps the plan.
+	diff, err = s.Plan(false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(diff.Added) != 0 || len(diff.Moved) != 0 || len(diff.Removed) != 0 || diff.Kept != len(stored) {
+		t.Errorf("second Plan() = %d added, %d moved, %d removed, %d kept, want all %d kept",
+			len(diff.Added), len(diff.Moved), len(diff.Removed), diff.Kept, len(stored))
+	}
+}
+
+func TestReplanFlights(t *testing.T) {
+	graph := testGraph(t)
+	now := time.Now()
+	flight := arrival(1, "S1", now.Add(time.Hour), 20)
+	other := arrival(2, "S2", now.Add(2*time.Hour), 20)
+
+	tests := []struct {
+		name   string
+		change func(flight models.Flight) models.Flight
+		check  func(t *testing.T, flight models.Flight, tasks []models.Task)
+	}{
+		{
+			name: "delayed",
+			change: func(flight models.Flight) models.Flight {
+				estimated := flight.ScheduledTime.Add(30 * time.Minute)
+				flight.EstimatedTime = &estimated
+				return flight
+			},
+			check: func(t *testing.T, flight models.Flight, tasks []models.Task) {
+				if len(tasks) != 1 {
+					t.Fatalf("flight has %d tasks, want 1", len(tasks))
+				}
+				if got := pickupTime(tasks[0]); !got.Equal(flight.Time()) {
+					t.Errorf("pickup at %s, want %s", got, flight.Time())
+				}
+			},
+		},
+		{
+			name: "more passengers",
+			change: func(flight models.Flight) models.Flight {
+				flight.Passengers = 45
+				return flight
+			},
+			check: func(t *testing.T, flight models.Flight, tasks []models.Task) {
+				carried := 0
+				for _, task := range tasks {
+					carried += task.Passengers
+				}
+				if carried != 45 {
+					t.Errorf("carried %d passengers, want 45", carried)
+				}
+			},
+		},
+		{
+			name: "cancelled",
+			change: func(flight models.Flight) models.Flight {
+				flight.Status = models.FlightStatusCancelled
+				return flight
+			},
+			check: func(t *testing.T, flight models.Flight, tasks []models.Task) {
+				if len(tasks) != 0 {
+					t.Errorf("cancelled flight has %d tasks", len(tasks))
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flights := flightstorage.New([]models.Flight{flight, other})
+			tasks := taskstorage.New()
+			buses := []models.Bus{workingBus(1, 30), workingBus(2, 30)}
+			storages := Storages{
+				Flights: flights,
+				Buses:   busstorage.New(buses),
+				Tasks:   tasks,
+			}
+			s := NewWithStorages(storages, staticGraph{graph}, Greedy{}, ServiceTimes{}, 3*time.Hour)
+
+			if _, err := s.Plan(false); err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			before, _ := tasks.GetTasks()
+
+			changed := tt.change(flight)
+			flights.UpdateFlight(changed)
+			if _, err := s.ReplanFlights([]int{changed.Id}); err != nil {
+				t.Fatalf("ReplanFlights() error = %v", err)
+			}
+
+			after, _ := tasks.GetTasks()
+			var own []models.Task
+			for _, task := range after {
+				if task.FlightID == changed.Id {
+					own = append(own, task)
+				}
+			}
+			tt.check(t, changed, own)
+			checkSchedule(t, graph, after, states(buses, time.Time{}))
+
+			// The tasks of the other flight are left alone.
+			for _, task := range before {
+				if task.FlightID != other.Id {
+					continue
+				}
+				found := false
+				for _, a := range after {
+					found = found || a.Id == task.Id && a.BusID == task.BusID && pickupTime(a).Equal(pickupTime(task))
+				}
+				if !found {
+					t.Errorf("task %d of the unchanged flight was changed", task.Id)
+				}
+			}
+		})
+	}
+}
+
+// TestReplanFlightsOutOfWindow checks that a change moving the flight out
+// of the planning window does not drop its tasks.
+func TestReplanFlightsOutOfWindow(t *testing.T) {
+	graph := testGraph(t)
+	now := time.Now()
+	flight := arrival(1, "S1", now.Add(20*time.Minute), 20)
+
+	tests := []struct {
+		name   string
+		change func(flight models.Flight) models.Flight
+		want   int // tasks of the flight
+	}{
+		{
+			name: "delayed past the window",
+			change: func(flight models.Flight) models.Flight {
+				estimated := flight.ScheduledTime.Add(20 * time.Minute)
+				flight.EstimatedTime = &estimated
+				return flight
+			},
+			want: 1,
+		},
+		{
+			name: "landed just before now",
+			change: func(flight models.Flight) models.Flight {
+				actual := now.Add(-time.Minute)
+				flight.ActualTime = &actual
+				return flight
+			},
+			want: 1,
+		},
+		{
+			name:   "deleted",
+			change: nil,
+			want:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flights := flightstorage.New([]models.Flight{flight})
+			tasks := taskstorage.New()
+			storages := Storages{
+				Flights: flights,
+				Buses:   busstorage.New([]models.Bus{workingBus(1, 30)}),
+				Tasks:   tasks,
+			}
+			s := NewWithStorages(storages, staticGraph{graph}, Greedy{}, ServiceTimes{}, 30*time.Minute)
+
+			if _, err := s.Plan(false); err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if before, _ := tasks.GetTasks(); len(before) != 1 {
+				t.Fatalf("Plan() stored %d tasks, want 1", len(before))
+			}
+
+			if tt.change == nil {
+				flights.DeleteFlight(flight.Id)
+			} else {
+				flights.UpdateFlight(tt.change(flight))
+			}
+			if _, err := s.ReplanFlights([]int{flight.Id}); err != nil {
+				t.Fatalf("ReplanFlights() error = %v", err)
+			}
+			// The next cycle does not see the flight and leaves its tasks.
+			if _, err := s.Plan(false); err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+
+			after, _ := tasks.GetTasks()
+			if len(after) != tt.want {
+				t.Fatalf("flight has %d tasks, want %d", len(after), tt.want)
+			}
+			if tt.change == nil {
+				return
+			}
+			if changed, _ := flights.GetFlight(flight.Id); changed.EstimatedTime != nil {
+				if got := pickupTime(after[0]); !got.Equal(changed.Time()) {
+					t.Errorf("pickup at %s, want %s", got, changed.Time())
+				}
+			}
+		})
+	}
+}
+
+// TestPlanDepartures plans with the 30 minute interval of cmd/bus-managment.
+// Passengers of a departure board long before it leaves, so a departure is
+// planned once its pickup, not the flight, is within the interval.
+func TestPlanDepartures(t *testing.T) {
+	graph := testGraph(t)
+	now := time.Now()
+
+	departure := func(id int, at time.Time) models.Flight {
+		flight := arrival(id, "S1", at, 20)
+		flight.Direction = models.FlightDeparture
+		return flight
+	}
+	soon := departure(1, now.Add(70*time.Minute))
+	later := departure(2, now.Add(3*time.Hour))
+
+	tasks := taskstorage.New()
+	storages := Storages{
+		Flights: flightstorage.New([]models.Flight{soon, later}),
+		Buses:   busstorage.New([]models.Bus{workingBus(1, 30)}),
+		Tasks:   tasks,
+	}
+	s := NewWithStorages(storages, staticGraph{graph}, Greedy{}, ServiceTimes{}, 30*time.Minute)
+
+	diff, err := s.Plan(false)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].FlightID != soon.Id {
+		t.Fatalf("Plan() added %+v, want a task of flight %d", diff.Added, soon.Id)
+	}
+	task := diff.Added[0]
+	if pickup := pickupTime(task); pickup.Before(now) || pickup.After(now.Add(30*time.Minute)) {
+		t.Errorf("pickup at %s, want within 30 minutes from %s", pickup, now)
+	}
+	unloading, _ := task.Leg(models.LegUnloading)
+	if end := unloading.PlannedEnd; end.After(soon.Time().Add(-departureLead)) {
+		t.Errorf("passengers at the aircraft at %s, want by %s", end, soon.Time().Add(-departureLead))
+	}
+}
+
+func TestPlanSkipsUnservableFlights(t *testing.T) {
+	graph := testGraph(t)
+	now := time.Now()
+
+	noGate := arrival(1, "S1", now.Add(time.Hour), 20)
+	noGate.Gate = ""
+	unknownStand := arrival(2, "S9", now.Add(time.Hour), 20)
+	servable := arrival(3, "S2", now.Add(time.Hour), 20)
+
+	tasks := taskstorage.New()
+	storages := Storages{
+		Flights: flightstorage.New([]models.Flight{noGate, unknownStand, servable}),
+		Buses:   busstorage.New([]models.Bus{workingBus(1, 30), workingBus(2, 30)}),
+		Tasks:   tasks,
+	}
+	s := NewWithStorages(storages, staticGraph{graph}, MinCostFlow{}, ServiceTimes{}, 3*time.Hour)
+
+	if _, err := s.Plan(false); err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	stored, _ := tasks.GetTasks()
+	if len(stored) != 1 || stored[0].FlightID != servable.Id {
+		t.Errorf("planned %v, want one task of flight %d", ids(stored), servable.Id)
+	}
+}
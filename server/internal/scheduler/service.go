@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/config"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// ServiceTime is how long the passengers of a flight take to board a bus
+// and to leave it.
+type ServiceTime struct {
+	Boarding  time.Duration
+	Unloading time.Duration
+}
+
+type serviceKey struct {
+	aircraftType string
+	direction    string
+}
+
+// ServiceTimes is a table of service times keyed by aircraft type and flight
+// direction. The zero value gives the default times to every flight.
+type ServiceTimes struct {
+	times map[serviceKey]ServiceTime
+}
+
+// NewServiceTimes builds the table from the config. An entry without an
+// aircraft type or a direction matches every type or direction, so an entry
+// without both replaces the default times. Zero durations are taken from the
+// less specific entry.
+func NewServiceTimes(entries []config.ServiceTime) ServiceTimes {
+	t := ServiceTimes{times: make(map[serviceKey]ServiceTime, len(entries))}
+	for _, entry := range entries {
+		t.times[serviceKey{entry.AircraftType, entry.Direction}] = ServiceTime{
+			Boarding:  entry.Boarding,
+			Unloading: entry.Unloading,
+		}
+	}
+	return t
+}
+
+// Longest returns the longest boarding plus unloading time of any flight.
+func (t ServiceTimes) Longest() time.Duration {
+	longest := ServiceTime{Boarding: boardingTime, Unloading: unloadingTime}
+	for _, entry := range t.times {
+		if entry.Boarding > longest.Boarding {
+			longest.Boarding = entry.Boarding
+		}
+		if entry.Unloading > longest.Unloading {
+			longest.Unloading = entry.Unloading
+		}
+	}
+	return longest.Boarding + longest.Unloading
+}
+
+// For returns the service time of the flight. The entry for its aircraft
+// type and direction is preferred, then the one for the type, then the one
+// for the direction, then the default.
+func (t ServiceTimes) For(flight models.Flight) ServiceTime {
+	result := ServiceTime{Boarding: boardingTime, Unloading: unloadingTime}
+
+	keys := []serviceKey{
+		{"", ""},
+		{"", flight.Direction},
+		{flight.AircraftType, ""},
+		{flight.AircraftType, flight.Direction},
+	}
+	for _, key := range keys {
+		entry, ok := t.times[key]
+		if !ok {
+			continue
+		}
+		if entry.Boarding > 0 {
+			result.Boarding = entry.Boarding
+		}
+		if entry.Unloading > 0 {
+			result.Unloading = entry.Unloading
+		}
+	}
+
+	return result
+}
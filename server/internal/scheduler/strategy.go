@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+const (
+	defaultBusCapacity = 30
+	transferTime       = 15 * time.Minute // time to carry passengers of a task stored without legs
+	departureLead      = 30 * time.Minute // passengers of a departure are at the aircraft this long before it leaves
+	longestDrive       = time.Hour        // no bus drives longer from a gate to a stand
+
+	StrategyGreedy  = "greedy"
+	StrategyMinCost = "mincost"
+)
+
+// Strategy distributes flights between buses.
+type Strategy interface {
+	Schedule(flights []models.Flight, buses []BusState, graph *distancegraph.Distancegraph, times ServiceTimes) []models.Task
+}
+
+// BusState tells where and since when a bus is free for new tasks.
+type BusState struct {
+	models.Bus
+	Location string
+	FreeAt   time.Time
+}
+
+func NewStrategy(name string) (Strategy, error) {
+	const op = "scheduler.NewStrategy"
+
+	switch name {
+	case StrategyGreedy, "":
+		return Greedy{}, nil
+	case StrategyMinCost:
+		return MinCostFlow{}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown strategy %q", op, name)
+	}
+}
+
+// departureTime returns when a bus that is free at the vertex from since
+// the given time should leave to reach the vertex to by the deadline.
+// The bus leaves as late as possible, false means it cannot make it.
+func departureTime(graph *distancegraph.Distancegraph, from string, to string, free time.Time, deadline time.Time) (time.Time, bool) {
+	travel, ok := graph.TravelTime(from, to, free)
+	if !ok || free.Add(travel).After(deadline) {
+		return time.Time{}, false
+	}
+
+	latest := deadline.Add(-travel)
+	if travel, ok := graph.TravelTime(from, to, latest); ok && !latest.Add(travel).After(deadline) {
+		return latest, true
+	}
+
+	return free, true
+}
+
+// capacity returns how many passengers the bus takes.
+func capacity(bus BusState) int {
+	if bus.Capacity <= 0 {
+		return defaultBusCapacity
+	}
+	return bus.Capacity
+}
+
+// byCapacity sorts buses from the largest to the smallest, so that flights
+// are served with the fewest trips.
+func byCapacity(buses []BusState) []BusState {
+	sorted := make([]BusState, len(buses))
+	copy(sorted, buses)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return capacity(sorted[i]) > capacity(sorted[j])
+	})
+	return sorted
+}
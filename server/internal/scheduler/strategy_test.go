@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+func TestStrategies(t *testing.T) {
+	graph := testGraph(t)
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+
+	offDuty := workingBus(3, 30)
+	offDuty.Status = models.BusStatusOffDuty
+
+	tests := []struct {
+		name      string
+		flights   []models.Flight
+		buses     []models.Bus
+		wantTasks int
+		wantPax   int
+	}{
+		{
+			name:      "flight split between two buses",
+			flights:   []models.Flight{arrival(1, "S1", base.Add(time.Hour), 50)},
+			buses:     []models.Bus{workingBus(1, 30), workingBus(2, 30)},
+			wantTasks: 2,
+			wantPax:   50,
+		},
+		{
+			name: "one bus serves two flights in turn",
+			flights: []models.Flight{
+				arrival(1, "S1", base.Add(time.Hour), 20),
+				arrival(2, "S2", base.Add(2*time.Hour), 20),
+			},
+			buses:     []models.Bus{workingBus(1, 30)},
+			wantTasks: 2,
+			wantPax:   40,
+		},
+		{
+			name:      "flight too soon to reach",
+			flights:   []models.Flight{arrival(1, "S1", base.Add(2*time.Minute), 20)},
+			buses:     []models.Bus{workingBus(1, 30)},
+			wantTasks: 0,
+		},
+		{
+			name: "stand missing from the graph",
+			flights: []models.Flight{
+				arrival(1, "S9", base.Add(time.Hour), 20),
+				arrival(2, "S1", base.Add(time.Hour), 20),
+			},
+			buses:     []models.Bus{workingBus(1, 30)},
+			wantTasks: 1,
+			wantPax:   20,
+		},
+		{
+			name:      "bus off duty",
+			flights:   []models.Flight{arrival(1, "S1", base.Add(time.Hour), 20)},
+			buses:     []models.Bus{offDuty},
+			wantTasks: 0,
+		},
+		{
+			name: "overlapping flights need two buses",
+			flights: []models.Flight{
+				arrival(1, "S1", base.Add(time.Hour), 20),
+				arrival(2, "S2", base.Add(time.Hour+5*time.Minute), 20),
+			},
+			buses:     []models.Bus{workingBus(1, 30)},
+			wantTasks: 1,
+			wantPax:   20,
+		},
+	}
+
+	strategies := []struct {
+		name     string
+		strategy Strategy
+	}{
+		{StrategyGreedy, Greedy{}},
+		{StrategyMinCost, MinCostFlow{}},
+	}
+
+	for _, st := range strategies {
+		for _, tt := range tests {
+			t.Run(st.name+"/"+tt.name, func(t *testing.T) {
+				buses := states(tt.buses, base)
+				tasks := st.strategy.Schedule(tt.flights, buses, graph, ServiceTimes{})
+
+				if len(tasks) != tt.wantTasks {
+					t.Fatalf("Schedule() returned %d tasks, want %d", len(tasks), tt.wantTasks)
+				}
+				metrics := Evaluate(tasks, tt.flights, buses, graph)
+				if metrics.CoveredPassengers != tt.wantPax {
+					t.Errorf("covered %d passengers, want %d", metrics.CoveredPassengers, tt.wantPax)
+				}
+				if metrics.LatePickups != 0 {
+					t.Errorf("%d late pickups", metrics.LatePickups)
+				}
+				checkSchedule(t, graph, tasks, buses)
+			})
+		}
+	}
+}
+
+func TestMinCostFlowShortensEmptyRun(t *testing.T) {
+	graph := testGraph(t)
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+
+	// The bus at the gate is closer to S2, the bus at the parking to S1.
+	buses := states([]models.Bus{workingBus(1, 30), workingBus(2, 30)}, base)
+	buses[1].Location = "G"
+	flights := []models.Flight{
+		arrival(1, "S1", base.Add(time.Hour), 20),
+		arrival(2, "S2", base.Add(time.Hour), 20),
+	}
+
+	tasks := MinCostFlow{}.Schedule(flights, buses, graph, ServiceTimes{})
+
+	metrics := Evaluate(tasks, flights, buses, graph)
+	if metrics.CoveredPassengers != 40 {
+		t.Fatalf("covered %d passengers, want 40", metrics.CoveredPassengers)
+	}
+	if want := 4.5 + 3.0; metrics.EmptyDistance != want {
+		t.Errorf("empty run = %v km, want %v km", metrics.EmptyDistance, want)
+	}
+}
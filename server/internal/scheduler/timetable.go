@@ -0,0 +1,262 @@
+package scheduler
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+)
+
+// timetable holds the unfinished tasks of the working buses and fits single
+// tasks into the gaps between them. Unlike a Strategy it does not need the
+// buses to be free after their last task.
+type timetable struct {
+	graph     *distancegraph.Distancegraph
+	states    []BusState
+	schedules map[int][]models.Task
+	changed   map[int]models.Task // stored tasks moved by assign, by id
+}
+
+func newTimetable(graph *distancegraph.Distancegraph, states []BusState, tasks []models.Task) *timetable {
+	t := &timetable{
+		graph:     graph,
+		states:    states,
+		schedules: make(map[int][]models.Task, len(states)),
+		changed:   make(map[int]models.Task),
+	}
+	for _, task := range tasks {
+		if task.Status != models.TaskStatusComplete {
+			t.schedules[task.BusID] = append(t.schedules[task.BusID], task)
+		}
+	}
+	for _, schedule := range t.schedules {
+		sortByStart(schedule)
+	}
+	return t
+}
+
+// assign fits the task with the pickup at the destination to the bus with
+// the shortest empty run. The preferred bus is taken whenever it fits.
+func (t *timetable) assign(task models.Task, destination string, preferred int) (models.Task, bool) {
+	best := -1
+	bestDistance := math.Inf(1)
+	var bestTask models.Task
+	var bestNext int
+	var bestAdjusted models.Task
+
+	for i, state := range t.states {
+		if capacity(state) < task.Passengers {
+			continue
+		}
+		candidate, next, adjusted, distance, ok := fit(t.graph, state, t.schedules[state.Id], task, destination)
+		if !ok {
+			continue
+		}
+		if state.Id == preferred {
+			distance = math.Inf(-1)
+		}
+		if distance < bestDistance {
+			best, bestDistance, bestTask, bestNext, bestAdjusted = i, distance, candidate, next, adjusted
+		}
+	}
+
+	if best == -1 {
+		return task, false
+	}
+
+	busID := t.states[best].Id
+	schedule := t.schedules[busID]
+	if bestNext != -1 {
+		schedule[bestNext] = bestAdjusted
+		if bestAdjusted.Id != 0 {
+			t.changed[bestAdjusted.Id] = bestAdjusted
+		}
+	}
+	t.schedules[busID] = append(schedule, bestTask)
+	sortByStart(t.schedules[busID])
+
+	if bestTask.Id != 0 {
+		t.changed[bestTask.Id] = bestTask
+	}
+
+	return bestTask, true
+}
+
+// keep puts the stored task back into the schedule of its bus as it is.
+func (t *timetable) keep(task models.Task) {
+	t.schedules[task.BusID] = append(t.schedules[task.BusID], task)
+	sortByStart(t.schedules[task.BusID])
+}
+
+// release re-times the task following the removed one on its bus, so that
+// it starts from where the bus is without the removed task. A task the bus
+// cannot make from there is left as it is.
+func (t *timetable) release(removed models.Task) {
+	state, ok := t.state(removed.BusID)
+	if !ok {
+		return
+	}
+
+	schedule := t.schedules[removed.BusID]
+	location, free := state.Location, state.FreeAt
+	for i, other := range schedule {
+		if other.TimeStart.Before(removed.TimeStart) {
+			vertex, end, ok := freeAfter(other)
+			if ok {
+				location = vertex
+			}
+			if end.After(free) {
+				free = end
+			}
+			continue
+		}
+
+		if empty, ok := other.Leg(models.LegEmpty); ok && empty.From == location {
+			return
+		}
+		destination, ok := pickupVertex(other)
+		if !ok {
+			return
+		}
+		start, ok := departureTime(t.graph, location, destination, free, pickupTime(other))
+		if !ok {
+			return
+		}
+		if !setStart(t.graph, &other, location, start) {
+			return
+		}
+		schedule[i] = other
+		if other.Id != 0 {
+			t.changed[other.Id] = other
+		}
+		return
+	}
+}
+
+func (t *timetable) state(busID int) (BusState, bool) {
+	for _, state := range t.states {
+		if state.Id == busID {
+			return state, true
+		}
+	}
+	return BusState{}, false
+}
+
+// added returns the assigned tasks that are not stored yet.
+func (t *timetable) added() []models.Task {
+	var tasks []models.Task
+	for _, schedule := range t.schedules {
+		for _, task := range schedule {
+			if task.Id == 0 {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
+
+// moved returns the stored tasks changed by assign.
+func (t *timetable) moved() []models.Task {
+	tasks := make([]models.Task, 0, len(t.changed))
+	for _, task := range t.changed {
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// fit inserts the task with the pickup at the destination into the schedule
+// of the bus. It returns the task with new bus, start time and empty leg,
+// the index of the following task in the schedule (-1 if there is none) with
+// its start time and empty leg adjusted to the new location, and the empty
+// run to the task in km.
+func fit(graph *distancegraph.Distancegraph, state BusState, schedule []models.Task, task models.Task, destination string) (models.Task, int, models.Task, float64, bool) {
+	pickup := pickupTime(task)
+
+	location, free := state.Location, state.FreeAt
+	next := -1
+	for i, other := range schedule {
+		vertex, end, ok := freeAfter(other)
+		if end.After(pickup) {
+			next = i
+			break
+		}
+		if ok {
+			location = vertex
+		}
+		if end.After(free) {
+			free = end
+		}
+	}
+
+	start, ok := departureTime(graph, location, destination, free, pickup)
+	if !ok {
+		return task, -1, models.Task{}, 0, false
+	}
+
+	task.BusID = state.Id
+	// A stored task keeps its status, the driver may have started it.
+	if task.Id == 0 {
+		task.Status = models.TaskStatusQueue
+	}
+	task.From = destination
+	if !setStart(graph, &task, location, start) {
+		return task, -1, models.Task{}, 0, false
+	}
+
+	if next == -1 {
+		return task, -1, models.Task{}, graph.MinDistance(location, destination), true
+	}
+
+	adjusted := schedule[next]
+	nextDestination, ok := pickupVertex(adjusted)
+	if !ok {
+		return task, -1, models.Task{}, 0, false
+	}
+	dropoff, _ := dropoffVertex(task)
+	nextStart, ok := departureTime(graph, dropoff, nextDestination, task.TimeEnd, pickupTime(adjusted))
+	if !ok {
+		return task, -1, models.Task{}, 0, false
+	}
+
+	if !setStart(graph, &adjusted, dropoff, nextStart) {
+		return task, -1, models.Task{}, 0, false
+	}
+
+	return task, next, adjusted, graph.MinDistance(location, destination), true
+}
+
+// pickupVertex returns where the bus picks up the passengers of the task.
+// Tasks planned before pickup points were stored end their route there.
+func pickupVertex(task models.Task) (string, bool) {
+	if task.From != "" {
+		return task.From, true
+	}
+	if len(task.Route) == 0 {
+		return "", false
+	}
+	return task.Route[len(task.Route)-1], true
+}
+
+// dropoffVertex returns where the bus is after the task.
+func dropoffVertex(task models.Task) (string, bool) {
+	if task.To != "" {
+		return task.To, true
+	}
+	return pickupVertex(task)
+}
+
+// pickupTime returns when the passengers of the task are picked up.
+func pickupTime(task models.Task) time.Time {
+	if leg, ok := task.Leg(models.LegBoarding); ok {
+		return leg.PlannedStart
+	}
+	return task.TimeEnd.Add(-transferTime)
+}
+
+func sortByStart(tasks []models.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].TimeStart.Before(tasks[j].TimeStart)
+	})
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+func TestFit(t *testing.T) {
+	graph := testGraph(t)
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	state := states([]models.Bus{workingBus(1, 30)}, base)[0]
+
+	// stored is a task of the bus, inserted is the task fitted around it.
+	stored := func(stand string, at time.Duration) models.Task {
+		flight := arrival(2, stand, base.Add(at), 20)
+		task, ok := newTask(graph, ServiceTimes{}, 1, flight, 20, "P", base, flight.Time())
+		if !ok {
+			t.Fatal("newTask() failed")
+		}
+		task.Id = 7
+		return task
+	}
+	inserted := arrival(1, "S1", base.Add(time.Hour), 20)
+
+	tests := []struct {
+		name         string
+		schedule     []models.Task
+		wantOK       bool
+		wantNext     int
+		wantLocation string  // where the bus starts the inserted task from
+		wantDistance float64 // km
+	}{
+		{
+			name:         "empty schedule",
+			wantOK:       true,
+			wantNext:     -1,
+			wantLocation: "P",
+			wantDistance: 4.5,
+		},
+		{
+			name:         "after a task",
+			schedule:     []models.Task{stored("S2", 20*time.Minute)},
+			wantOK:       true,
+			wantNext:     -1,
+			wantLocation: "G",
+			wantDistance: 3,
+		},
+		{
+			name:         "before a task",
+			schedule:     []models.Task{stored("S2", 2*time.Hour)},
+			wantOK:       true,
+			wantNext:     0,
+			wantLocation: "P",
+			wantDistance: 4.5,
+		},
+		{
+			name:     "gap too short",
+			schedule: []models.Task{stored("S2", time.Hour+10*time.Minute)},
+			wantOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			legs, ok := passengerLegs(graph, ServiceTimes{}, inserted, inserted.Time())
+			if !ok {
+				t.Fatal("passengerLegs() failed")
+			}
+			task := models.Task{
+				FlightID:   inserted.Id,
+				Passengers: 20,
+				TimeEnd:    legs[len(legs)-1].PlannedEnd,
+				To:         inserted.Dropoff(),
+				Legs:       legs,
+			}
+
+			got, next, adjusted, distance, fitted := fit(graph, state, tt.schedule, task, inserted.Pickup())
+			if fitted != tt.wantOK {
+				t.Fatalf("fit() ok = %v, want %v", fitted, tt.wantOK)
+			}
+			if !fitted {
+				return
+			}
+
+			if next != tt.wantNext {
+				t.Errorf("fit() next = %d, want %d", next, tt.wantNext)
+			}
+			if distance != tt.wantDistance {
+				t.Errorf("fit() distance = %v, want %v", distance, tt.wantDistance)
+			}
+			if got.BusID != state.Id || got.Status != models.TaskStatusQueue || got.From != inserted.Pickup() {
+				t.Errorf("fit() task = bus %d, status %q, from %q", got.BusID, got.Status, got.From)
+			}
+			empty, _ := got.Leg(models.LegEmpty)
+			if empty.From != tt.wantLocation {
+				t.Errorf("empty leg from %q, want %q", empty.From, tt.wantLocation)
+			}
+			if empty.PlannedEnd.After(pickupTime(got)) {
+				t.Errorf("bus arrives at %s, after the pickup at %s", empty.PlannedEnd, pickupTime(got))
+			}
+
+			if next == -1 {
+				return
+			}
+			// The following task now starts from the drop-off of the inserted one.
+			nextEmpty, _ := adjusted.Leg(models.LegEmpty)
+			if nextEmpty.From != got.To || nextEmpty.PlannedStart.Before(got.TimeEnd) {
+				t.Errorf("next task leaves %q at %s, want %q after %s", nextEmpty.From, nextEmpty.PlannedStart, got.To, got.TimeEnd)
+			}
+			if adjusted.Id != tt.schedule[next].Id {
+				t.Errorf("adjusted task id = %d, want %d", adjusted.Id, tt.schedule[next].Id)
+			}
+		})
+	}
+}
+
+// TestFitKeepsStatus checks that a stored task moved to another bus keeps the
+// status the driver gave it, the scheduler does not change statuses.
+func TestFitKeepsStatus(t *testing.T) {
+	graph := testGraph(t)
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	state := states([]models.Bus{workingBus(1, 30)}, base)[0]
+
+	flight := arrival(1, "S1", base.Add(time.Hour), 20)
+	task, ok := newTask(graph, ServiceTimes{}, 2, flight, 20, "P", base, flight.Time())
+	if !ok {
+		t.Fatal("newTask() failed")
+	}
+	task.Id = 7
+	task.Status = models.TaskStatusWork
+
+	got, _, _, _, ok := fit(graph, state, nil, task, flight.Pickup())
+	if !ok {
+		t.Fatal("fit() failed")
+	}
+	if got.BusID != state.Id || got.Status != models.TaskStatusWork {
+		t.Errorf("fit() task = bus %d, status %q, want bus %d, status %q", got.BusID, got.Status, state.Id, models.TaskStatusWork)
+	}
+}
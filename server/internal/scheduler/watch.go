@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// Watch replans the flights from the changes until the context is done or
+// the channel is closed. Changes arriving within the delay of each other are
+// replanned together, so a batch of updates triggers one cycle. The result of
+// every cycle is passed to report.
+func (s *scheduler) Watch(ctx context.Context, changes <-chan models.FlightChange, delay time.Duration, report func(Diff, error)) {
+	pending := make(map[int]bool)
+	timer := time.NewTimer(delay)
+	timer.Stop()
+
+	flush := func() {
+		ids := make([]int, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		pending = make(map[int]bool)
+		report(s.ReplanFlights(ids))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case change, ok := <-changes:
+			if !ok {
+				timer.Stop()
+				if len(pending) > 0 {
+					flush()
+				}
+				return
+			}
+			if len(pending) == 0 {
+				timer.Reset(delay)
+			}
+			pending[change.FlightID] = true
+		case <-timer.C:
+			flush()
+		}
+	}
+}
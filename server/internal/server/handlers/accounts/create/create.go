@@ -0,0 +1,108 @@
+package create
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	accountstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/account-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// minPasswordLength is the shortest password accepted for an account.
+const minPasswordLength = 8
+
+type Request struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+type Response struct {
+	resp.Response
+	Account models.Account `json:"account"`
+}
+
+type AccountAdder interface {
+	AddAccount(account models.Account) (models.Account, error)
+}
+
+// New adds a dispatcher or an admin account.
+func New(log *slog.Logger, accountAdder AccountAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.accounts.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.String("login", req.Login), slog.String("role", req.Role))
+
+		switch {
+		case req.Login == "":
+			log.Error("login is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("login is required"))
+			return
+		case req.Role != models.RoleDispatcher && req.Role != models.RoleAdmin:
+			log.Error("wrong role", slog.String("role", req.Role))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("role must be dispatcher or admin"))
+			return
+		case len(req.Password) < minPasswordLength:
+			log.Error("password is too short")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("password is too short"))
+			return
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			log.Error("failed to hash password", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		account, err := accountAdder.AddAccount(models.Account{Login: req.Login, Role: req.Role, PasswordHash: hash})
+		if errors.Is(err, accountstorage.ErrAccountExists) {
+			log.Error("account exists", slog.String("login", req.Login))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.Error("account already exists"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to add account", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("account added", slog.Int("id", account.Id))
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{Response: resp.OK(), Account: account})
+	}
+}
@@ -0,0 +1,73 @@
+package driver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"golang.org/x/exp/slog"
+)
+
+type fakeDrivers map[int]models.Driver
+
+func (f fakeDrivers) GetDriver(driverID int) (models.Driver, error) {
+	driver, ok := f[driverID]
+	if !ok {
+		return models.Driver{}, driverstorage.ErrDriverNotFound
+	}
+	return driver, nil
+}
+
+func (f fakeDrivers) CurrentShift(driverID int, at time.Time) (models.Shift, error) {
+	return models.Shift{}, driverstorage.ErrShiftNotFound
+}
+
+type fakeTokens struct{}
+
+func (fakeTokens) Issue(user auth.User) (string, time.Time, error) {
+	return "token", time.Now().Add(time.Hour), nil
+}
+
+func TestSignIn(t *testing.T) {
+	hash, err := auth.HashPassword("12345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	drivers := fakeDrivers{
+		1: {Id: 1, Name: "Ivan Petrov", PinHash: hash},
+		2: {Id: 2, Name: "Petr Ivanov"},
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := New(log, drivers, drivers, fakeTokens{})
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "right pin", body: `{"id": "1", "name": "ivan petrov ", "pin": "12345678"}`, want: http.StatusOK},
+		{name: "wrong pin", body: `{"id": "1", "name": "Ivan Petrov", "pin": "87654321"}`, want: http.StatusUnauthorized},
+		{name: "no pin", body: `{"id": "1", "name": "Ivan Petrov"}`, want: http.StatusUnauthorized},
+		{name: "wrong name", body: `{"id": "1", "name": "Petr Ivanov", "pin": "12345678"}`, want: http.StatusUnauthorized},
+		{name: "driver without pin", body: `{"id": "2", "name": "Petr Ivanov", "pin": ""}`, want: http.StatusUnauthorized},
+		{name: "unknown driver", body: `{"id": "3", "name": "Ivan Petrov", "pin": "12345678"}`, want: http.StatusUnauthorized},
+		{name: "wrong id", body: `{"id": "one", "name": "Ivan Petrov", "pin": "12345678"}`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/auth/driver", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package login
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	accountstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/account-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Request struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type Response struct {
+	resp.Response
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+	Role    string    `json:"role"`
+}
+
+type AccountGetter interface {
+	GetAccount(login string) (models.Account, error)
+}
+
+type TokenIssuer interface {
+	Issue(user auth.User) (string, time.Time, error)
+}
+
+// New signs in a dispatcher or an admin with the login and the password.
+func New(log *slog.Logger, accountGetter AccountGetter, tokens TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.login.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		// The password is not logged.
+		log.Info("request body decoded", slog.String("login", req.Login))
+
+		account, err := accountGetter.GetAccount(req.Login)
+		if err != nil && !errors.Is(err, accountstorage.ErrAccountNotFound) {
+			log.Error("failed to get account", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+		hash := auth.DummyHash
+		if err == nil {
+			hash = account.PasswordHash
+		}
+		if !auth.CheckPassword(hash, req.Password) || err != nil {
+			log.Error("wrong login or password", slog.String("login", req.Login))
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("wrong login or password"))
+			return
+		}
+
+		token, expires, err := tokens.Issue(auth.User{ID: account.Id, Role: account.Role})
+		if err != nil {
+			log.Error("failed to issue token", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("signed in", slog.String("login", account.Login), slog.String("role", account.Role))
+		render.JSON(w, r, Response{Response: resp.OK(), Token: token, Expires: expires, Role: account.Role})
+	}
+}
@@ -0,0 +1,49 @@
+package ticket
+
+import (
+	"net/http"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Ticket  string    `json:"ticket"`
+	Expires time.Time `json:"expires"`
+}
+
+type TicketIssuer interface {
+	Issue(user auth.User) (string, time.Time, error)
+}
+
+// New issues a single-use ticket to open an event stream of the tasks as the
+// signed in user.
+func New(log *slog.Logger, tickets TicketIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.ticket.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, _ := auth.FromContext(r.Context())
+
+		ticket, expires, err := tickets.Issue(user)
+		if err != nil {
+			log.Error("failed to issue ticket", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("ticket issued", slog.Int("userID", user.ID), slog.String("role", user.Role))
+		render.JSON(w, r, Response{Response: resp.OK(), Ticket: ticket, Expires: expires})
+	}
+}
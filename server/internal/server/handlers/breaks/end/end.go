@@ -0,0 +1,68 @@
+package end
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type BreakEnder interface {
+	GetBus(busID int) (models.Bus, error)
+	EndBreak(busID int, end time.Time) error
+}
+
+func New(log *slog.Logger, breakEnder BreakEnder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.breaks.end.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		busID, err := strconv.Atoi(chi.URLParam(r, "busID"))
+		if err != nil {
+			log.Error("wrong busID format", sl.Err(err))
+			render.JSON(w, r, resp.Error("wrong busID format"))
+			return
+		}
+
+		bus, err := breakEnder.GetBus(busID)
+		if errors.Is(err, busstorage.ErrBusNotFound) {
+			log.Error("bus not found", slog.Int("busID", busID))
+			render.JSON(w, r, resp.Error("bus not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get bus", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		if bus.Status != models.BusStatusBreak {
+			log.Error("bus is not on break", slog.String("status", bus.Status))
+			render.JSON(w, r, resp.Error("bus is not on break"))
+			return
+		}
+
+		err = breakEnder.EndBreak(busID, time.Now())
+		if err != nil {
+			log.Error("failed to end break", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("break ended")
+		render.JSON(w, r, resp.OK())
+	}
+}
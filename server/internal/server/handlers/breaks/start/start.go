@@ -0,0 +1,123 @@
+package start
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/scheduler"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Request holds the planned duration of the break in minutes.
+type Request struct {
+	Duration int `json:"duration"`
+}
+
+type Response struct {
+	resp.Response
+	Break      models.Break  `json:"break"`
+	Reassigned []models.Task `json:"reassigned"`
+	Unassigned []models.Task `json:"unassigned"`
+}
+
+type BreakStarter interface {
+	StartBreak(busID int, start time.Time, plannedEnd time.Time) (models.Break, error)
+}
+
+type Reassigner interface {
+	ReassignBusTasks(busID int, from time.Time, to time.Time, statuses ...string) (scheduler.Reassignment, error)
+}
+
+func New(log *slog.Logger, breakStarter BreakStarter, reassigner Reassigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.breaks.start.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		busID, err := strconv.Atoi(chi.URLParam(r, "busID"))
+		if err != nil {
+			log.Error("wrong busID format", sl.Err(err))
+			render.JSON(w, r, resp.Error("wrong busID format"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if req.Duration <= 0 {
+			log.Error("wrong duration", slog.Int("duration", req.Duration))
+			render.JSON(w, r, resp.Error("duration must be positive"))
+			return
+		}
+
+		start := time.Now()
+		plannedEnd := start.Add(time.Duration(req.Duration) * time.Minute)
+
+		br, err := breakStarter.StartBreak(busID, start, plannedEnd)
+		if errors.Is(err, busstorage.ErrBusNotFound) {
+			log.Error("bus not found", slog.Int("busID", busID))
+			render.JSON(w, r, resp.Error("bus not found"))
+			return
+		}
+		if errors.Is(err, busstorage.ErrBusStatus) {
+			log.Error("wrong bus status", sl.Err(err))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.Error("bus can go on break only from status "+models.BusStatusWork))
+			return
+		}
+		if err != nil {
+			log.Error("failed to start break", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		// The break is taken, a failed reassignment leaves the tasks on the
+		// bus and is reported along with the break.
+		result, err := reassigner.ReassignBusTasks(busID, start, plannedEnd, models.TaskStatusQueue)
+		if err != nil {
+			log.Error("failed to reassign tasks", sl.Err(err))
+			render.JSON(w, r, Response{
+				Response: resp.Partial("break started, but tasks were not reassigned"),
+				Break:    br,
+			})
+			return
+		}
+
+		log.Info("break started",
+			slog.Int("reassigned", len(result.Reassigned)),
+			slog.Int("unassigned", len(result.Unassigned)),
+		)
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Break:      br,
+			Reassigned: result.Reassigned,
+			Unassigned: result.Unassigned,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package list
+
+import (
+	"net/http"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Buses []models.Bus `json:"buses"`
+}
+
+type BusesLister interface {
+	ListBuses() ([]models.Bus, error)
+}
+
+// New lists the buses, those in one status with the status parameter.
+func New(log *slog.Logger, busesLister BusesLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.buses.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		buses, err := busesLister.ListBuses()
+		if err != nil {
+			log.Error("failed to get buses", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		if status := r.URL.Query().Get("status"); status != "" {
+			filtered := make([]models.Bus, 0, len(buses))
+			for _, bus := range buses {
+				if bus.Status == status {
+					filtered = append(filtered, bus)
+				}
+			}
+			buses = filtered
+		}
+
+		log.Info("buses found and submitted", slog.Int("buses", len(buses)))
+		render.JSON(w, r, Response{Response: resp.OK(), Buses: buses})
+	}
+}
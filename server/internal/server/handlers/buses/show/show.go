@@ -0,0 +1,61 @@
+package show
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Bus models.Bus `json:"bus"`
+}
+
+type BusGetter interface {
+	GetBus(busID int) (models.Bus, error)
+}
+
+func New(log *slog.Logger, busGetter BusGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.buses.show.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		busID, err := strconv.Atoi(chi.URLParam(r, "busID"))
+		if err != nil {
+			log.Error("wrong busID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong busID format"))
+			return
+		}
+
+		bus, err := busGetter.GetBus(busID)
+		if errors.Is(err, busstorage.ErrBusNotFound) {
+			log.Error("bus not found", slog.Int("busID", busID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("bus not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get bus", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("bus found and submitted", slog.Int("busID", busID))
+		render.JSON(w, r, Response{Response: resp.OK(), Bus: bus})
+	}
+}
@@ -0,0 +1,97 @@
+package create
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Request struct {
+	Name string `json:"name"`
+}
+
+// Response carries the driver with the id and the PIN the driver signs in
+// with. The PIN is not stored and shown only once.
+type Response struct {
+	resp.Response
+	Driver models.Driver `json:"driver"`
+	PIN    string        `json:"pin"`
+}
+
+type DriverAdder interface {
+	AddDriver(driver models.Driver) (models.Driver, error)
+}
+
+func New(log *slog.Logger, driverAdder DriverAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.drivers.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			log.Error("name is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("name is required"))
+			return
+		}
+
+		pin, err := auth.NewPIN()
+		if err != nil {
+			log.Error("failed to generate pin", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		hash, err := auth.HashPassword(pin)
+		if err != nil {
+			log.Error("failed to hash pin", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		driver, err := driverAdder.AddDriver(models.Driver{Name: name, PinHash: hash})
+		if err != nil {
+			log.Error("failed to add driver", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("driver added", slog.Int("driverID", driver.Id))
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{Response: resp.OK(), Driver: driver, PIN: pin})
+	}
+}
@@ -0,0 +1,43 @@
+package list
+
+import (
+	"net/http"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Drivers []models.Driver `json:"drivers"`
+}
+
+type DriversGetter interface {
+	ListDrivers() ([]models.Driver, error)
+}
+
+func New(log *slog.Logger, driversGetter DriversGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.drivers.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		drivers, err := driversGetter.ListDrivers()
+		if err != nil {
+			log.Error("failed to get drivers", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("drivers found and submitted", slog.Int("drivers", len(drivers)))
+		render.JSON(w, r, Response{Response: resp.OK(), Drivers: drivers})
+	}
+}
@@ -0,0 +1,80 @@
+package pin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Response carries the new PIN of the driver. It is not stored and shown
+// only once.
+type Response struct {
+	resp.Response
+	PIN string `json:"pin"`
+}
+
+type PinSetter interface {
+	SetPinHash(driverID int, pinHash string) error
+}
+
+// New issues a new PIN to the driver, the old one stops working.
+func New(log *slog.Logger, pinSetter PinSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.drivers.pin.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		driverID, err := strconv.Atoi(chi.URLParam(r, "driverID"))
+		if err != nil {
+			log.Error("wrong driverID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong driverID format"))
+			return
+		}
+
+		pin, err := auth.NewPIN()
+		if err != nil {
+			log.Error("failed to generate pin", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		hash, err := auth.HashPassword(pin)
+		if err != nil {
+			log.Error("failed to hash pin", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		err = pinSetter.SetPinHash(driverID, hash)
+		if errors.Is(err, driverstorage.ErrDriverNotFound) {
+			log.Error("driver not found", slog.Int("driverID", driverID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("driver not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to set pin", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("pin issued", slog.Int("driverID", driverID))
+		render.JSON(w, r, Response{Response: resp.OK(), PIN: pin})
+	}
+}
@@ -0,0 +1,78 @@
+package show
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Response carries the current shift of the driver, nil if the driver is
+// off shift.
+type Response struct {
+	resp.Response
+	Driver models.Driver `json:"driver"`
+	Shift  *models.Shift `json:"shift,omitempty"`
+}
+
+type DriverGetter interface {
+	GetDriver(driverID int) (models.Driver, error)
+	CurrentShift(driverID int, at time.Time) (models.Shift, error)
+}
+
+func New(log *slog.Logger, driverGetter DriverGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.drivers.show.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		driverID, err := strconv.Atoi(chi.URLParam(r, "driverID"))
+		if err != nil {
+			log.Error("wrong driverID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong driverID format"))
+			return
+		}
+
+		driver, err := driverGetter.GetDriver(driverID)
+		if errors.Is(err, driverstorage.ErrDriverNotFound) {
+			log.Error("driver not found", slog.Int("driverID", driverID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("driver not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get driver", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		var shift *models.Shift
+		current, err := driverGetter.CurrentShift(driverID, time.Now())
+		switch {
+		case err == nil:
+			shift = &current
+		case !errors.Is(err, driverstorage.ErrShiftNotFound):
+			log.Error("failed to get current shift", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("driver found and submitted", slog.Int("driverID", driverID))
+		render.JSON(w, r, Response{Response: resp.OK(), Driver: driver, Shift: shift})
+	}
+}
@@ -0,0 +1,146 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// maxUpdates limits the size of a batch.
+const maxUpdates = 1000
+
+type Request struct {
+	Updates []models.FlightUpdate `json:"updates"`
+}
+
+// Rejection is an update of the batch that was not applied, by its index.
+type Rejection struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// Response counts the applied updates and those that lost to newer changes
+// of the same fields.
+type Response struct {
+	resp.Response
+	Applied  int         `json:"applied"`
+	Stale    int         `json:"stale"`
+	Rejected []Rejection `json:"rejected,omitempty"`
+}
+
+type FlightUpdater interface {
+	ApplyFlightUpdate(update models.FlightUpdate) (bool, error)
+}
+
+type GraphProvider interface {
+	Graph() *distancegraph.Distancegraph
+}
+
+// New accepts a batch of flight updates from the operational database of the
+// airport. Every update is applied on its own, invalid updates and updates of
+// unknown flights are reported in the response and do not stop the batch.
+func New(log *slog.Logger, flightUpdater FlightUpdater, graphProvider GraphProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flights.feed.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		if len(req.Updates) > maxUpdates {
+			log.Error("too many updates", slog.Int("updates", len(req.Updates)))
+			render.JSON(w, r, resp.Error(fmt.Sprintf("at most %d updates are accepted", maxUpdates)))
+			return
+		}
+
+		log.Info("request body decoded", slog.Int("updates", len(req.Updates)))
+
+		graph := graphProvider.Graph()
+		res := Response{Response: resp.OK()}
+		for i, update := range req.Updates {
+			if err := validate(update, graph); err != nil {
+				res.Rejected = append(res.Rejected, Rejection{Index: i, Error: err.Error()})
+				continue
+			}
+
+			changed, err := flightUpdater.ApplyFlightUpdate(update)
+			if errors.Is(err, flightstorage.ErrFlightNotFound) {
+				res.Rejected = append(res.Rejected, Rejection{Index: i, Error: "flight not found"})
+				continue
+			}
+			if errors.Is(err, flightstorage.ErrFlightStatus) {
+				res.Rejected = append(res.Rejected, Rejection{Index: i, Error: "unknown status"})
+				continue
+			}
+			if err != nil {
+				log.Error("failed to apply update", slog.String("number", update.Number), sl.Err(err))
+				res.Rejected = append(res.Rejected, Rejection{Index: i, Error: "internal error"})
+				continue
+			}
+
+			if changed {
+				res.Applied++
+			} else {
+				res.Stale++
+			}
+		}
+
+		log.Info("flight updates applied",
+			slog.Int("applied", res.Applied),
+			slog.Int("stale", res.Stale),
+			slog.Int("rejected", len(res.Rejected)),
+		)
+		render.JSON(w, r, res)
+	}
+}
+
+func validate(update models.FlightUpdate, graph *distancegraph.Distancegraph) error {
+	if update.Number == "" {
+		return errors.New("number is required")
+	}
+	if update.Direction != models.FlightArrival && update.Direction != models.FlightDeparture {
+		return errors.New("direction must be A or D")
+	}
+	if _, err := time.Parse("2006-01-02", update.Date); err != nil {
+		return errors.New("wrong date format")
+	}
+	if update.Time.IsZero() {
+		return errors.New("time is required")
+	}
+	if update.Stand != nil && !graph.HasVertex(*update.Stand) {
+		return errors.New("unknown stand")
+	}
+	if update.Gate != nil && !graph.HasVertex(*update.Gate) {
+		return errors.New("unknown gate")
+	}
+	if update.Passengers != nil && *update.Passengers < 0 {
+		return errors.New("passengers must not be negative")
+	}
+	return nil
+}
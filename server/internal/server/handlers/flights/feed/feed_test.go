@@ -0,0 +1,199 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/memory"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/aodb"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/middleware/token"
+	"golang.org/x/exp/slog"
+)
+
+type staticGraph struct {
+	graph *distancegraph.Distancegraph
+}
+
+func (g staticGraph) Graph() *distancegraph.Distancegraph {
+	return g.graph
+}
+
+// TestFeed sends updates with the AODB stub client to the webhook served like
+// in the server and checks the flight and its change notifications.
+func TestFeed(t *testing.T) {
+	graph, err := distancegraph.Build(
+		[]distancegraph.Vertex{
+			{Name: "23", Type: distancegraph.VertexStand},
+			{Name: "40", Type: distancegraph.VertexStand},
+			{Name: "DGA_I", Type: distancegraph.VertexGate},
+		},
+		[]distancegraph.Path{
+			{From: "23", To: "DGA_I", Length: 1}, {From: "DGA_I", To: "23", Length: 1},
+			{From: "40", To: "DGA_I", Length: 1}, {From: "DGA_I", To: "40", Length: 1},
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	scheduled := time.Date(2023, 10, 29, 14, 35, 0, 0, time.Local)
+	storage := flightstorage.New([]models.Flight{{
+		Id:            1,
+		Number:        "SU1234",
+		Direction:     models.FlightArrival,
+		Date:          "2023-10-29",
+		Stand:         "23",
+		Gate:          "DGA_I",
+		ScheduledTime: scheduled,
+		Status:        models.FlightStatusScheduled,
+		Passengers:    150,
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := storage.Changes(ctx)
+	if err != nil {
+		t.Fatalf("Changes() error = %v", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := httptest.NewServer(token.New("secret")(New(log, storage, staticGraph{graph})))
+	defer server.Close()
+	client := aodb.Client{URL: server.URL, Token: "secret"}
+
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	update := func(at time.Duration) models.FlightUpdate {
+		return models.FlightUpdate{Number: "SU1234", Direction: models.FlightArrival, Date: "2023-10-29", Time: base.Add(at)}
+	}
+	stand := func(at time.Duration, stand string) models.FlightUpdate {
+		u := update(at)
+		u.Stand = &stand
+		return u
+	}
+	status := func(at time.Duration, status string) models.FlightUpdate {
+		u := update(at)
+		u.Status = &status
+		return u
+	}
+	unknown := stand(0, "40")
+	unknown.Number = "SU9999"
+
+	steps := []struct {
+		name         string
+		updates      []models.FlightUpdate
+		wantApplied  int
+		wantStale    int
+		wantRejected []Rejection
+		wantChanges  int
+		wantStand    string
+		wantStatus   string
+	}{
+		{
+			name:        "new stand",
+			updates:     []models.FlightUpdate{stand(10*time.Minute, "40")},
+			wantApplied: 1,
+			wantChanges: 1,
+			wantStand:   "40",
+			wantStatus:  models.FlightStatusScheduled,
+		},
+		{
+			name:       "older stand loses",
+			updates:    []models.FlightUpdate{stand(5*time.Minute, "23")},
+			wantStale:  1,
+			wantStand:  "40",
+			wantStatus: models.FlightStatusScheduled,
+		},
+		{
+			name:        "older change of another field wins",
+			updates:     []models.FlightUpdate{status(5*time.Minute, models.FlightStatusCancelled)},
+			wantApplied: 1,
+			wantChanges: 1,
+			wantStand:   "40",
+			wantStatus:  models.FlightStatusCancelled,
+		},
+		{
+			name: "invalid updates are rejected",
+			updates: []models.FlightUpdate{
+				status(20*time.Minute, "delayed"),
+				stand(20*time.Minute, "99"),
+				unknown,
+				stand(20*time.Minute, "23"),
+			},
+			wantApplied: 1,
+			wantRejected: []Rejection{
+				{Index: 0, Error: "unknown status"},
+				{Index: 1, Error: "unknown stand"},
+				{Index: 2, Error: "flight not found"},
+			},
+			wantChanges: 1,
+			wantStand:   "23",
+			wantStatus:  models.FlightStatusCancelled,
+		},
+	}
+
+	for _, step := range steps {
+		code, body, err := client.Send(aodb.Batch{Updates: step.updates})
+		if err != nil {
+			t.Fatalf("%s: Send() error = %v", step.name, err)
+		}
+		if code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", step.name, code, body)
+		}
+
+		var res Response
+		if err := json.Unmarshal(body, &res); err != nil {
+			t.Fatalf("%s: decode %s: %v", step.name, body, err)
+		}
+		if res.Applied != step.wantApplied || res.Stale != step.wantStale {
+			t.Errorf("%s: applied %d, stale %d, want %d and %d", step.name, res.Applied, res.Stale, step.wantApplied, step.wantStale)
+		}
+		if len(res.Rejected) != len(step.wantRejected) {
+			t.Errorf("%s: rejected %v, want %v", step.name, res.Rejected, step.wantRejected)
+		} else {
+			for i := range res.Rejected {
+				if res.Rejected[i] != step.wantRejected[i] {
+					t.Errorf("%s: rejected %v, want %v", step.name, res.Rejected[i], step.wantRejected[i])
+				}
+			}
+		}
+
+		for i := 0; i < step.wantChanges; i++ {
+			select {
+			case change := <-changes:
+				if change.FlightID != 1 || change.Op != "UPDATE" {
+					t.Errorf("%s: change %+v, want an update of flight 1", step.name, change)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s: no change notification", step.name)
+			}
+		}
+		select {
+		case change := <-changes:
+			t.Errorf("%s: unexpected change %+v", step.name, change)
+		default:
+		}
+
+		flight, err := storage.GetFlight(1)
+		if err != nil {
+			t.Fatalf("%s: GetFlight() error = %v", step.name, err)
+		}
+		if flight.Stand != step.wantStand || flight.Status != step.wantStatus {
+			t.Errorf("%s: flight at stand %q with status %q, want %q and %q",
+				step.name, flight.Stand, flight.Status, step.wantStand, step.wantStatus)
+		}
+	}
+
+	// The webhook only accepts the token of the AODB.
+	client.Token = "wrong"
+	if code, _, err := client.Send(aodb.Batch{Updates: []models.FlightUpdate{stand(time.Hour, "40")}}); err != nil || code != http.StatusUnauthorized {
+		t.Errorf("Send() with a wrong token = %d, %v, want %d", code, err, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,75 @@
+package list
+
+import (
+	"net/http"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// defaultWindow is how far ahead flights are listed without the to parameter.
+const defaultWindow = 24 * time.Hour
+
+type Response struct {
+	resp.Response
+	Flights []models.Flight `json:"flights"`
+}
+
+type FlightsGetter interface {
+	GetFlightsBetween(from time.Time, to time.Time) ([]models.Flight, error)
+}
+
+// New lists the flights with the best known time between the from and to
+// parameters (format "2006-01-02 15:04:05"), by default the next day.
+func New(log *slog.Logger, flightsGetter FlightsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flights.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		layout := "2006-01-02 15:04:05"
+
+		from := time.Now()
+		if value := r.URL.Query().Get("from"); value != "" {
+			var err error
+			from, err = time.ParseInLocation(layout, value, time.Local)
+			if err != nil {
+				log.Error("wrong from format", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong from format"))
+				return
+			}
+		}
+
+		to := from.Add(defaultWindow)
+		if value := r.URL.Query().Get("to"); value != "" {
+			var err error
+			to, err = time.ParseInLocation(layout, value, time.Local)
+			if err != nil {
+				log.Error("wrong to format", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong to format"))
+				return
+			}
+		}
+
+		flights, err := flightsGetter.GetFlightsBetween(from, to)
+		if err != nil {
+			log.Error("failed to get flights", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("flights found and submitted", slog.Int("flights", len(flights)))
+		render.JSON(w, r, Response{Response: resp.OK(), Flights: flights})
+	}
+}
@@ -0,0 +1,61 @@
+package show
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Flight models.Flight `json:"flight"`
+}
+
+type FlightGetter interface {
+	GetFlight(flightID int) (models.Flight, error)
+}
+
+func New(log *slog.Logger, flightGetter FlightGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flights.show.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		flightID, err := strconv.Atoi(chi.URLParam(r, "flightID"))
+		if err != nil {
+			log.Error("wrong flightID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong flightID format"))
+			return
+		}
+
+		flight, err := flightGetter.GetFlight(flightID)
+		if errors.Is(err, flightstorage.ErrFlightNotFound) {
+			log.Error("flight not found", slog.Int("flightID", flightID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("flight not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get flight", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("flight found and submitted", slog.Int("flightID", flightID))
+		render.JSON(w, r, Response{Response: resp.OK(), Flight: flight})
+	}
+}
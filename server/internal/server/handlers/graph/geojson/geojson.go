@@ -0,0 +1,144 @@
+package geojson
+
+import (
+	"net/http"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string         `json:"type"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type Geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+type GraphProvider interface {
+	Graph() *distancegraph.Distancegraph
+}
+
+type TasksGetter interface {
+	GetActiveTasks() ([]models.Task, error)
+}
+
+// New exports vertices, edges and routes of active tasks as GeoJSON.
+// Vertices without coordinates are skipped.
+func New(log *slog.Logger, graphProvider GraphProvider, taskGetter TasksGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.graph.geojson.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		tasks, err := taskGetter.GetActiveTasks()
+		if err != nil {
+			log.Error("failed to get tasks", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		render.JSON(w, r, build(graphProvider.Graph(), tasks))
+		log.Info("geojson submitted")
+	}
+}
+
+func build(graph *distancegraph.Distancegraph, tasks []models.Task) FeatureCollection {
+	collection := FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+
+	coordinates := make(map[string][2]float64)
+	for _, vertex := range graph.Vertices() {
+		if !vertex.HasCoordinates() {
+			continue
+		}
+		point := [2]float64{vertex.Lon, vertex.Lat}
+		coordinates[vertex.Name] = point
+
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			Geometry: Geometry{Type: "Point", Coordinates: point},
+			Properties: map[string]any{
+				"kind": "vertex",
+				"name": vertex.Name,
+				"type": vertex.Type,
+			},
+		})
+	}
+
+	added := make(map[[2]string]bool)
+	for _, path := range graph.Paths() {
+		if added[[2]string{path.To, path.From}] {
+			continue
+		}
+		line, ok := lineString(coordinates, []string{path.From, path.To})
+		if !ok {
+			continue
+		}
+		added[[2]string{path.From, path.To}] = true
+
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			Geometry: line,
+			Properties: map[string]any{
+				"kind":   "edge",
+				"from":   path.From,
+				"to":     path.To,
+				"length": path.Length,
+			},
+		})
+	}
+
+	for _, task := range tasks {
+		line, ok := lineString(coordinates, task.Route)
+		if !ok {
+			continue
+		}
+
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			Geometry: line,
+			Properties: map[string]any{
+				"kind":     "route",
+				"taskID":   task.Id,
+				"busID":    task.BusID,
+				"flightID": task.FlightID,
+				"status":   task.Status,
+			},
+		})
+	}
+
+	return collection
+}
+
+func lineString(coordinates map[string][2]float64, route []string) (Geometry, bool) {
+	if len(route) < 2 {
+		return Geometry{}, false
+	}
+
+	line := make([][2]float64, 0, len(route))
+	for _, vertex := range route {
+		point, ok := coordinates[vertex]
+		if !ok {
+			return Geometry{}, false
+		}
+		line = append(line, point)
+	}
+
+	return Geometry{Type: "LineString", Coordinates: line}, true
+}
@@ -0,0 +1,36 @@
+package reload
+
+import (
+	"net/http"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type GraphReloader interface {
+	Reload() error
+}
+
+func New(log *slog.Logger, graphReloader GraphReloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.graph.reload.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		err := graphReloader.Reload()
+		if err != nil {
+			log.Error("failed to reload graph", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to reload graph"))
+			return
+		}
+
+		log.Info("graph reloaded")
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,100 @@
+package restrict
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Request struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Start    string  `json:"start"`
+	End      string  `json:"end"`
+	MaxSpeed float64 `json:"maxSpeed"` // 0 closes the edge
+}
+
+type RestrictionAdder interface {
+	AddRestriction(distancegraph.Restriction) error
+}
+
+type GraphReloader interface {
+	Reload() error
+}
+
+func New(log *slog.Logger, restrictionAdder RestrictionAdder, graphReloader GraphReloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.graph.restrict.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		layout := "2006-01-02 15:04:05"
+		start, err := time.ParseInLocation(layout, req.Start, time.Local)
+		if err != nil {
+			log.Error("wrong start format", sl.Err(err))
+			render.JSON(w, r, resp.Error("wrong start format"))
+			return
+		}
+		end, err := time.ParseInLocation(layout, req.End, time.Local)
+		if err != nil {
+			log.Error("wrong end format", sl.Err(err))
+			render.JSON(w, r, resp.Error("wrong end format"))
+			return
+		}
+		if !end.After(start) || req.MaxSpeed < 0 {
+			log.Error("wrong restriction")
+			render.JSON(w, r, resp.Error("wrong restriction"))
+			return
+		}
+
+		err = restrictionAdder.AddRestriction(distancegraph.Restriction{
+			From:     req.From,
+			To:       req.To,
+			Start:    start,
+			End:      end,
+			MaxSpeed: req.MaxSpeed,
+		})
+		if err != nil {
+			log.Error("failed to add restriction", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		err = graphReloader.Reload()
+		if err != nil {
+			log.Error("failed to reload graph", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to reload graph"))
+			return
+		}
+
+		log.Info("restriction added")
+		render.JSON(w, r, resp.OK())
+	}
+}
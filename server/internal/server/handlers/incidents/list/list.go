@@ -0,0 +1,51 @@
+package list
+
+import (
+	"net/http"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Incidents []models.Incident `json:"incidents"`
+}
+
+type IncidentsGetter interface {
+	GetIncidents(status string) ([]models.Incident, error)
+}
+
+func New(log *slog.Logger, incidentsGetter IncidentsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.incidents.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", models.IncidentStatusOpen, models.IncidentStatusResolved:
+		default:
+			log.Error("wrong status", slog.String("status", status))
+			render.JSON(w, r, resp.Error("wrong status"))
+			return
+		}
+
+		incidents, err := incidentsGetter.GetIncidents(status)
+		if err != nil {
+			log.Error("failed to get incidents", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("incidents found and submitted")
+		render.JSON(w, r, Response{Response: resp.OK(), Incidents: incidents})
+	}
+}
@@ -0,0 +1,147 @@
+package report
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/scheduler"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Request struct {
+	BusID       int    `json:"busID"`
+	TaskID      int    `json:"taskID"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type Response struct {
+	resp.Response
+	Incident   models.Incident `json:"incident"`
+	Reassigned []models.Task   `json:"reassigned"`
+	Unassigned []models.Task   `json:"unassigned"`
+}
+
+type IncidentAdder interface {
+	AddIncident(incident models.Incident) (int, error)
+}
+
+type BusStatusChanger interface {
+	TakeOutOfService(busID int, at time.Time) (string, error)
+}
+
+type Reassigner interface {
+	ReassignBusTasks(busID int, from time.Time, to time.Time, statuses ...string) (scheduler.Reassignment, error)
+}
+
+// New registers an incident, takes the bus out of service and moves its
+// in-work and queued tasks to other buses.
+func New(log *slog.Logger, incidentAdder IncidentAdder, busStatusChanger BusStatusChanger, reassigner Reassigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.incidents.report.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		user, _ := auth.FromContext(r.Context())
+		if user.Role == models.RoleDriver && user.BusID != req.BusID {
+			log.Error("incident of another bus", slog.Int("busID", req.BusID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+			return
+		}
+
+		switch req.Type {
+		case models.IncidentBreakdown, models.IncidentAccident, models.IncidentPassenger:
+		default:
+			log.Error("wrong incident type", slog.String("type", req.Type))
+			render.JSON(w, r, resp.Error("wrong incident type"))
+			return
+		}
+
+		now := time.Now()
+
+		status, err := busStatusChanger.TakeOutOfService(req.BusID, now)
+		if errors.Is(err, busstorage.ErrBusNotFound) {
+			log.Error("bus not found", slog.Int("busID", req.BusID))
+			render.JSON(w, r, resp.Error("bus not found"))
+			return
+		}
+		if errors.Is(err, busstorage.ErrBusStatus) {
+			log.Error("wrong bus status", sl.Err(err))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.Error("bus cannot go out of service from status "+status))
+			return
+		}
+		if err != nil {
+			log.Error("failed to take bus out of service", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		incident := models.Incident{
+			BusID:       req.BusID,
+			TaskID:      req.TaskID,
+			Type:        req.Type,
+			Description: req.Description,
+			Status:      models.IncidentStatusOpen,
+			TimeCreated: now,
+		}
+
+		incident.Id, err = incidentAdder.AddIncident(incident)
+		if err != nil {
+			log.Error("failed to add incident", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		result, err := reassigner.ReassignBusTasks(req.BusID, now, time.Time{}, models.TaskStatusWork, models.TaskStatusPause, models.TaskStatusQueue)
+		if err != nil {
+			log.Error("failed to reassign tasks", sl.Err(err))
+			render.JSON(w, r, Response{
+				Response: resp.Partial("incident registered, but tasks were not reassigned"),
+				Incident: incident,
+			})
+			return
+		}
+
+		log.Info("incident registered",
+			slog.Int("id", incident.Id),
+			slog.Int("reassigned", len(result.Reassigned)),
+			slog.Int("unassigned", len(result.Unassigned)),
+		)
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Incident:   incident,
+			Reassigned: result.Reassigned,
+			Unassigned: result.Unassigned,
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package resolve
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	incidentstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/incident-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Request holds the dispatcher's resolution. If ReturnToService is set and
+// the bus has no other open incidents, the bus goes back to work.
+type Request struct {
+	Resolution      string `json:"resolution"`
+	ReturnToService bool   `json:"returnToService"`
+}
+
+type Response struct {
+	resp.Response
+	Incident models.Incident `json:"incident"`
+}
+
+type IncidentResolver interface {
+	ResolveIncident(incidentID int, resolution string, at time.Time) (models.Incident, error)
+	HasOpenIncidents(busID int) (bool, error)
+}
+
+type BusStatusChanger interface {
+	GetBus(busID int) (models.Bus, error)
+	ChangeBusStatus(busID int, newStatus string) error
+}
+
+func New(log *slog.Logger, incidentResolver IncidentResolver, busStatusChanger BusStatusChanger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.incidents.resolve.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		incidentID, err := strconv.Atoi(chi.URLParam(r, "incidentID"))
+		if err != nil {
+			log.Error("wrong incidentID format", sl.Err(err))
+			render.JSON(w, r, resp.Error("wrong incidentID format"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		incident, err := incidentResolver.ResolveIncident(incidentID, req.Resolution, time.Now())
+		if errors.Is(err, incidentstorage.ErrIncidentNotFound) {
+			log.Error("open incident not found", slog.Int("incidentID", incidentID))
+			render.JSON(w, r, resp.Error("open incident not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to resolve incident", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		if req.ReturnToService {
+			open, err := incidentResolver.HasOpenIncidents(incident.BusID)
+			if err != nil {
+				log.Error("failed to check open incidents", sl.Err(err))
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+
+			bus, err := busStatusChanger.GetBus(incident.BusID)
+			if err != nil {
+				log.Error("failed to get bus", sl.Err(err))
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+
+			if !open && bus.Status == models.BusStatusOutOfService {
+				err = busStatusChanger.ChangeBusStatus(incident.BusID, models.BusStatusWork)
+				if err != nil {
+					log.Error("failed to change bus status", sl.Err(err))
+					render.JSON(w, r, resp.Error("internal error"))
+					return
+				}
+				log.Info("bus returned to service", slog.Int("busID", incident.BusID))
+			}
+		}
+
+		log.Info("incident resolved", slog.Int("id", incident.Id))
+		render.JSON(w, r, Response{Response: resp.OK(), Incident: incident})
+	}
+}
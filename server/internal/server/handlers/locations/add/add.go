@@ -0,0 +1,108 @@
+package add
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Request holds either a vertex of the graph or coordinates.
+// Coordinates are snapped to the nearest vertex.
+type Request struct {
+	Vertex string  `json:"vertex"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+}
+
+type LocationAdder interface {
+	AddLocation(models.Location) error
+}
+
+type GraphProvider interface {
+	Graph() *distancegraph.Distancegraph
+}
+
+func New(log *slog.Logger, locationAdder LocationAdder, graphProvider GraphProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.locations.add.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		busID, err := strconv.Atoi(chi.URLParam(r, "busID"))
+		if err != nil {
+			log.Error("wrong busID format", sl.Err(err))
+			render.JSON(w, r, resp.Error("wrong busID format"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		location := models.Location{
+			BusID:  busID,
+			Vertex: req.Vertex,
+			Lat:    req.Lat,
+			Lon:    req.Lon,
+			Time:   time.Now(),
+		}
+
+		graph := graphProvider.Graph()
+		switch {
+		case location.Vertex != "":
+			if !graph.HasVertex(location.Vertex) {
+				log.Error("unknown vertex", slog.String("vertex", location.Vertex))
+				render.JSON(w, r, resp.Error("unknown vertex"))
+				return
+			}
+		case location.Lat != 0 || location.Lon != 0:
+			vertex, ok := graph.Nearest(location.Lat, location.Lon)
+			if !ok {
+				log.Error("graph has no coordinates")
+				render.JSON(w, r, resp.Error("vertex is required"))
+				return
+			}
+			location.Vertex = vertex.Name
+		default:
+			log.Error("location is empty")
+			render.JSON(w, r, resp.Error("vertex or coordinates are required"))
+			return
+		}
+
+		err = locationAdder.AddLocation(location)
+		if err != nil {
+			log.Error("failed to add location", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("location added", slog.String("vertex", location.Vertex))
+		render.JSON(w, r, resp.OK())
+	}
+}
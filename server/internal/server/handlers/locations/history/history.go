@@ -0,0 +1,63 @@
+package history
+
+import (
+	"net/http"
+	"strconv"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+const defaultLimit = 100
+
+type Response struct {
+	resp.Response
+	Locations []models.Location `json:"locations"`
+}
+
+type LocationsGetter interface {
+	GetBusLocations(busID int, limit int) ([]models.Location, error)
+}
+
+func New(log *slog.Logger, locationsGetter LocationsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.locations.history.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		busID, err := strconv.Atoi(chi.URLParam(r, "busID"))
+		if err != nil {
+			log.Error("wrong busID format", sl.Err(err))
+			render.JSON(w, r, resp.Error("wrong busID format"))
+			return
+		}
+
+		limit := defaultLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit <= 0 {
+				log.Error("wrong limit format")
+				render.JSON(w, r, resp.Error("wrong limit format"))
+				return
+			}
+		}
+
+		locations, err := locationsGetter.GetBusLocations(busID, limit)
+		if err != nil {
+			log.Error("failed to get locations", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("locations found and submitted")
+		render.JSON(w, r, Response{Response: resp.OK(), Locations: locations})
+	}
+}
@@ -0,0 +1,62 @@
+package replan
+
+import (
+	"net/http"
+	"strconv"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/scheduler"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	DryRun bool `json:"dryRun"`
+	scheduler.Diff
+}
+
+type Planner interface {
+	Plan(dryRun bool) (scheduler.Diff, error)
+}
+
+// New runs a planning cycle. With the dryRun parameter the diff is returned
+// without changing the tasks.
+func New(log *slog.Logger, planner Planner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.schedule.replan.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		dryRun := false
+		if value := r.URL.Query().Get("dryRun"); value != "" {
+			var err error
+			dryRun, err = strconv.ParseBool(value)
+			if err != nil {
+				log.Error("wrong dryRun format", sl.Err(err))
+				render.JSON(w, r, resp.Error("wrong dryRun format"))
+				return
+			}
+		}
+
+		diff, err := planner.Plan(dryRun)
+		if err != nil {
+			log.Error("failed to plan tasks", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("tasks planned",
+			slog.Bool("dryRun", dryRun),
+			slog.Int("added", len(diff.Added)),
+			slog.Int("moved", len(diff.Moved)),
+			slog.Int("removed", len(diff.Removed)),
+		)
+		render.JSON(w, r, Response{Response: resp.OK(), DryRun: dryRun, Diff: diff})
+	}
+}
@@ -0,0 +1,57 @@
+package checkin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Shift models.Shift `json:"shift"`
+}
+
+type ShiftChecker interface {
+	CheckIn(driverID int, at time.Time) (models.Shift, error)
+}
+
+// New checks the signed in driver in on the shift that starts now or soon.
+// From then on the shift is current until the driver checks out.
+func New(log *slog.Logger, shiftChecker ShiftChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.shifts.checkin.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, _ := auth.FromContext(r.Context())
+
+		shift, err := shiftChecker.CheckIn(user.ID, time.Now())
+		if errors.Is(err, driverstorage.ErrShiftNotFound) {
+			log.Error("no shift to check in", slog.Int("driverID", user.ID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("no shift to check in"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check in", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("driver checked in", slog.Int("driverID", user.ID), slog.Int("shiftID", shift.Id))
+		render.JSON(w, r, Response{Response: resp.OK(), Shift: shift})
+	}
+}
@@ -0,0 +1,57 @@
+package checkout
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Shift models.Shift `json:"shift"`
+}
+
+type ShiftChecker interface {
+	CheckOut(driverID int, at time.Time) (models.Shift, error)
+}
+
+// New checks the signed in driver out of the shift. The driver has no bus
+// after that until the next shift.
+func New(log *slog.Logger, shiftChecker ShiftChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.shifts.checkout.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, _ := auth.FromContext(r.Context())
+
+		shift, err := shiftChecker.CheckOut(user.ID, time.Now())
+		if errors.Is(err, driverstorage.ErrShiftNotFound) {
+			log.Error("not checked in", slog.Int("driverID", user.ID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("not checked in"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check out", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("driver checked out", slog.Int("driverID", user.ID), slog.Int("shiftID", shift.Id))
+		render.JSON(w, r, Response{Response: resp.OK(), Shift: shift})
+	}
+}
@@ -0,0 +1,117 @@
+package create
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Request puts the driver on the bus from time start to time end.
+type Request struct {
+	DriverID  int       `json:"driverID"`
+	BusID     int       `json:"busID"`
+	TimeStart time.Time `json:"time start"`
+	TimeEnd   time.Time `json:"time end"`
+}
+
+type Response struct {
+	resp.Response
+	Shift models.Shift `json:"shift"`
+}
+
+type ShiftAdder interface {
+	AddShift(shift models.Shift) (models.Shift, error)
+}
+
+type BusGetter interface {
+	GetBus(busID int) (models.Bus, error)
+}
+
+func New(log *slog.Logger, shiftAdder ShiftAdder, busGetter BusGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.shifts.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if req.TimeStart.IsZero() || !req.TimeEnd.After(req.TimeStart) {
+			log.Error("wrong time", slog.Time("start", req.TimeStart), slog.Time("end", req.TimeEnd))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("time end must be after time start"))
+			return
+		}
+
+		_, err = busGetter.GetBus(req.BusID)
+		if errors.Is(err, busstorage.ErrBusNotFound) {
+			log.Error("bus not found", slog.Int("busID", req.BusID))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("bus not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get bus", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		shift, err := shiftAdder.AddShift(models.Shift{
+			DriverID:  req.DriverID,
+			BusID:     req.BusID,
+			TimeStart: req.TimeStart,
+			TimeEnd:   req.TimeEnd,
+		})
+		if errors.Is(err, driverstorage.ErrDriverNotFound) {
+			log.Error("driver not found", slog.Int("driverID", req.DriverID))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("driver not found"))
+			return
+		}
+		if errors.Is(err, driverstorage.ErrShiftOverlap) {
+			log.Error("shift overlaps", slog.Int("driverID", req.DriverID), slog.Int("busID", req.BusID))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.Error("shift overlaps another shift of the driver or the bus"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to add shift", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("shift added", slog.Int("shiftID", shift.Id))
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{Response: resp.OK(), Shift: shift})
+	}
+}
@@ -0,0 +1,93 @@
+package list
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// defaultWindow is how far ahead shifts are listed without the to parameter.
+const defaultWindow = 24 * time.Hour
+
+type Response struct {
+	resp.Response
+	Shifts []models.Shift `json:"shifts"`
+}
+
+type ShiftsGetter interface {
+	GetShifts(driverID int, from time.Time, to time.Time) ([]models.Shift, error)
+}
+
+// New lists the roster: the shifts between the from and to parameters
+// (format "2006-01-02 15:04:05"), by default the next day, those of one
+// driver with the driverID parameter. Drivers only get their own shifts.
+func New(log *slog.Logger, shiftsGetter ShiftsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.shifts.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		layout := "2006-01-02 15:04:05"
+
+		from := time.Now()
+		if value := r.URL.Query().Get("from"); value != "" {
+			var err error
+			from, err = time.ParseInLocation(layout, value, time.Local)
+			if err != nil {
+				log.Error("wrong from format", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong from format"))
+				return
+			}
+		}
+
+		to := from.Add(defaultWindow)
+		if value := r.URL.Query().Get("to"); value != "" {
+			var err error
+			to, err = time.ParseInLocation(layout, value, time.Local)
+			if err != nil {
+				log.Error("wrong to format", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong to format"))
+				return
+			}
+		}
+
+		driverID := 0
+		if value := r.URL.Query().Get("driverID"); value != "" {
+			var err error
+			driverID, err = strconv.Atoi(value)
+			if err != nil {
+				log.Error("wrong driverID format", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong driverID format"))
+				return
+			}
+		}
+		if user, _ := auth.FromContext(r.Context()); user.Role == models.RoleDriver {
+			driverID = user.ID
+		}
+
+		shifts, err := shiftsGetter.GetShifts(driverID, from, to)
+		if err != nil {
+			log.Error("failed to get shifts", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("shifts found and submitted", slog.Int("shifts", len(shifts)))
+		render.JSON(w, r, Response{Response: resp.OK(), Shifts: shifts})
+	}
+}
@@ -0,0 +1,55 @@
+package remove
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type ShiftDeleter interface {
+	DeleteShift(shiftID int) error
+}
+
+func New(log *slog.Logger, shiftDeleter ShiftDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.shifts.remove.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		shiftID, err := strconv.Atoi(chi.URLParam(r, "shiftID"))
+		if err != nil {
+			log.Error("wrong shiftID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong shiftID format"))
+			return
+		}
+
+		err = shiftDeleter.DeleteShift(shiftID)
+		if errors.Is(err, driverstorage.ErrShiftNotFound) {
+			log.Error("shift not found", slog.Int("shiftID", shiftID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("shift not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete shift", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("shift deleted", slog.Int("shiftID", shiftID))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -2,23 +2,29 @@ package change
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/etag"
 	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"golang.org/x/exp/slog"
 )
 
 const (
-	StatusWork     = "in work"
-	StatusPause    = "on pause"
-	StatusComplete = "complete"
-	StatusQueue    = "queue"
+	StatusWork      = models.TaskStatusWork
+	StatusPause     = models.TaskStatusPause
+	StatusComplete  = models.TaskStatusComplete
+	StatusQueue     = models.TaskStatusQueue
+	StatusCancelled = models.TaskStatusCancelled
 )
 
 type Request struct {
@@ -29,12 +35,24 @@ type Request struct {
 	} `json:"parameter"`
 }
 
+// ConflictResponse carries the current task when the If-Match header does
+// not match its version.
+type ConflictResponse struct {
+	resp.Response
+	Task models.Task `json:"task"`
+}
+
 type TasksChanger interface {
-	ChangeTaskStatus(int, string) error
-	ChangeTaskTime(int, time.Time) error
-	ChangeTaskBus(int, int) error
+	GetTask(int) (models.Task, error)
+	ChangeTaskStatus(taskID int, status string, version int, userID int, role string) (models.Task, error)
+	ChangeTaskTime(taskID int, time time.Time, version int, userID int, role string) (models.Task, error)
+	ChangeTaskBus(taskID int, busID int, version int, userID int, role string) (models.Task, error)
 }
 
+// New changes one parameter of the task. With the If-Match header the task
+// is only changed if its ETag, the version, still matches; otherwise the
+// answer is 409 with the current task. Without the header the task must not
+// change between its reading here and the change.
 func New(log *slog.Logger, taskChanger TasksChanger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.tasks.change.New"
@@ -67,15 +85,83 @@ func New(log *slog.Logger, taskChanger TasksChanger) http.HandlerFunc {
 			return
 		}
 
+		version, err := etag.Version(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Error("wrong If-Match format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong If-Match format"))
+			return
+		}
+
+		// The task may also be deleted between its reading and the change.
+		notFound := func() {
+			log.Error("task not found", slog.Int("taskID", taskID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("task not found"))
+		}
+
+		task, err := taskChanger.GetTask(taskID)
+		if errors.Is(err, taskstorage.ErrTaskNotFound) {
+			notFound()
+			return
+		}
+		if err != nil {
+			log.Error("failed to get task", sl.Err(err))
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		user, _ := auth.FromContext(r.Context())
+		if !auth.CanChangeTask(user, task, req.Parameter.Type) {
+			log.Error("task change is forbidden", slog.String("role", user.Role), slog.String("type", req.Parameter.Type))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+			return
+		}
+
+		conflict := func(current models.Task) {
+			log.Error("task version conflict", slog.Int("taskID", taskID), slog.Int("version", version), slog.Int("current", current.Version))
+			w.Header().Set("ETag", etag.Format(current.Version))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, ConflictResponse{Response: resp.Error("task was changed, reload it"), Task: current})
+		}
+		// The task was checked as read here, so without If-Match it must not
+		// change, e.g. move to another bus, before the change either.
+		if version == 0 {
+			version = task.Version
+		}
+		if version != task.Version {
+			conflict(task)
+			return
+		}
+
 		switch req.Parameter.Type {
 		case "status":
-			if req.Parameter.Value != StatusComplete && req.Parameter.Value != StatusPause &&
-				req.Parameter.Value != StatusWork && req.Parameter.Value != StatusQueue {
+			if !models.IsTaskStatus(req.Parameter.Value) {
 				log.Error("wrong status")
 				render.JSON(w, r, resp.Error("wrong status"))
 				return
 			}
-			err := taskChanger.ChangeTaskStatus(taskID, req.Parameter.Value)
+			// A repeated request of the app changes nothing.
+			if req.Parameter.Value == task.Status {
+				break
+			}
+			task, err = taskChanger.ChangeTaskStatus(taskID, req.Parameter.Value, version, user.ID, user.Role)
+			if errors.Is(err, taskstorage.ErrVersionConflict) {
+				conflict(task)
+				return
+			}
+			if errors.Is(err, taskstorage.ErrTaskNotFound) {
+				notFound()
+				return
+			}
+			var transition *taskstorage.StatusTransitionError
+			if errors.As(err, &transition) {
+				log.Error("illegal status transition", slog.String("from", transition.From), slog.String("to", transition.To))
+				render.Status(r, http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error(fmt.Sprintf("cannot change status from %s to %s", transition.From, transition.To)))
+				return
+			}
 			if err != nil {
 				log.Error("failed to change task", sl.Err(err))
 				render.JSON(w, r, resp.Error("internal error"))
@@ -90,7 +176,20 @@ func New(log *slog.Logger, taskChanger TasksChanger) http.HandlerFunc {
 				render.JSON(w, r, resp.Error("wrong time format"))
 				return
 			}
-			err = taskChanger.ChangeTaskTime(taskID, time)
+			task, err = taskChanger.ChangeTaskTime(taskID, time, version, user.ID, user.Role)
+			if errors.Is(err, taskstorage.ErrVersionConflict) {
+				conflict(task)
+				return
+			}
+			if errors.Is(err, taskstorage.ErrTaskNotFound) {
+				notFound()
+				return
+			}
+			if errors.Is(err, taskstorage.ErrTaskTime) {
+				log.Error("wrong time", slog.Time("time", time))
+				render.JSON(w, r, resp.Error("time end must be after time start"))
+				return
+			}
 			if err != nil {
 				log.Error("failed to change task", sl.Err(err))
 				render.JSON(w, r, resp.Error("internal error"))
@@ -104,7 +203,15 @@ func New(log *slog.Logger, taskChanger TasksChanger) http.HandlerFunc {
 				render.JSON(w, r, resp.Error("wrong busID format"))
 				return
 			}
-			err = taskChanger.ChangeTaskBus(taskID, busID)
+			task, err = taskChanger.ChangeTaskBus(taskID, busID, version, user.ID, user.Role)
+			if errors.Is(err, taskstorage.ErrVersionConflict) {
+				conflict(task)
+				return
+			}
+			if errors.Is(err, taskstorage.ErrTaskNotFound) {
+				notFound()
+				return
+			}
 			if err != nil {
 				log.Error("failed to change task", sl.Err(err))
 				render.JSON(w, r, resp.Error("internal error"))
@@ -118,6 +225,7 @@ func New(log *slog.Logger, taskChanger TasksChanger) http.HandlerFunc {
 		}
 
 		log.Info("task changed correctly")
+		w.Header().Set("ETag", etag.Format(task.Version))
 		render.JSON(w, r, resp.OK())
 	}
 }
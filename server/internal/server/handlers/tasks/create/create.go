@@ -0,0 +1,172 @@
+package create
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/etag"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Request is a task added by the dispatcher by hand. It has no route and
+// legs, the bus drives to the pickup point on its own. Empty From and To are
+// taken from the flight.
+type Request struct {
+	BusID      int       `json:"busID"`
+	FlightID   int       `json:"flightID"`
+	Passengers int       `json:"passengers"`
+	TimeStart  time.Time `json:"time start"`
+	TimeEnd    time.Time `json:"time end"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+}
+
+type Response struct {
+	resp.Response
+	Task models.Task `json:"task"`
+}
+
+type TaskAdder interface {
+	AddTask(task models.Task, userID int, role string) (models.Task, error)
+}
+
+type BusGetter interface {
+	GetBus(busID int) (models.Bus, error)
+}
+
+type FlightGetter interface {
+	GetFlight(flightID int) (models.Flight, error)
+}
+
+type GraphProvider interface {
+	Graph() *distancegraph.Distancegraph
+}
+
+// New adds the task. A flight or a pickup or drop-off point that does not
+// exist gives 422.
+func New(log *slog.Logger, taskAdder TaskAdder, busGetter BusGetter, flightGetter FlightGetter, graphProvider GraphProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		switch {
+		case req.FlightID <= 0:
+			log.Error("flightID is required")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("flightID is required"))
+			return
+		case req.Passengers <= 0:
+			log.Error("wrong passengers", slog.Int("passengers", req.Passengers))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("passengers must be positive"))
+			return
+		case req.TimeStart.IsZero() || !req.TimeEnd.After(req.TimeStart):
+			log.Error("wrong time", slog.Time("start", req.TimeStart), slog.Time("end", req.TimeEnd))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("time end must be after time start"))
+			return
+		}
+
+		_, err = busGetter.GetBus(req.BusID)
+		if errors.Is(err, busstorage.ErrBusNotFound) {
+			log.Error("bus not found", slog.Int("busID", req.BusID))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("bus not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get bus", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		flight, err := flightGetter.GetFlight(req.FlightID)
+		if errors.Is(err, flightstorage.ErrFlightNotFound) {
+			log.Error("flight not found", slog.Int("flightID", req.FlightID))
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, resp.Error("flight not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get flight", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		if req.From == "" {
+			req.From = flight.Pickup()
+		}
+		if req.To == "" {
+			req.To = flight.Dropoff()
+		}
+		graph := graphProvider.Graph()
+		for _, vertex := range []string{req.From, req.To} {
+			if !graph.HasVertex(vertex) {
+				log.Error("unknown vertex", slog.String("vertex", vertex))
+				render.Status(r, http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("unknown point "+strconv.Quote(vertex)))
+				return
+			}
+		}
+
+		user, _ := auth.FromContext(r.Context())
+
+		task, err := taskAdder.AddTask(models.Task{
+			BusID:      req.BusID,
+			FlightID:   req.FlightID,
+			Passengers: req.Passengers,
+			TimeStart:  req.TimeStart,
+			TimeEnd:    req.TimeEnd,
+			Status:     models.TaskStatusQueue,
+			From:       req.From,
+			To:         req.To,
+		}, user.ID, user.Role)
+		if err != nil {
+			log.Error("failed to add task", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("task added", slog.Int("taskID", task.Id))
+		w.Header().Set("ETag", etag.Format(task.Version))
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{Response: resp.OK(), Task: task})
+	}
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// pingInterval keeps proxies from closing an idle stream.
+const pingInterval = 30 * time.Second
+
+// eventNames are the SSE event names of the task actions.
+var eventNames = map[int]string{
+	models.TaskActionUpdate: "update",
+	models.TaskActionDelete: "delete",
+	models.TaskActionCreate: "create",
+}
+
+type Subscriber interface {
+	Subscribe(busID int) (<-chan models.TaskEvent, func())
+}
+
+// New streams task events as server-sent events, those of one bus with the
+// busID parameter. The stream ends when the client falls behind, the client
+// then reconnects and reloads the tasks.
+func New(log *slog.Logger, subscriber Subscriber) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.events.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		busID := 0
+		if value := r.URL.Query().Get("busID"); value != "" {
+			var err error
+			busID, err = strconv.Atoi(value)
+			if err != nil || busID <= 0 {
+				log.Error("wrong busID format")
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong busID format"))
+				return
+			}
+		}
+
+		// Drivers only follow the bus of their current shift.
+		if user, _ := auth.FromContext(r.Context()); user.Role == models.RoleDriver {
+			if user.BusID == 0 {
+				log.Error("driver is off shift", slog.Int("driverID", user.ID))
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("no current shift"))
+				return
+			}
+			busID = user.BusID
+		}
+
+		// The stream outlives the write timeout of the server.
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			log.Error("failed to reset write deadline", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("streaming is not supported"))
+			return
+		}
+
+		events, cancel := subscriber.Subscribe(busID)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			log.Error("failed to flush", sl.Err(err))
+			return
+		}
+
+		log.Info("task stream opened", slog.Int("busID", busID))
+
+		ping := time.NewTicker(pingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				log.Info("task stream closed by client")
+				return
+			case event, ok := <-events:
+				if !ok {
+					log.Info("task stream closed")
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					log.Error("failed to encode event", sl.Err(err))
+					continue
+				}
+				_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventNames[event.Action], data)
+				if err == nil {
+					err = rc.Flush()
+				}
+				if err != nil {
+					log.Error("failed to send event", sl.Err(err))
+					return
+				}
+			case <-ping.C:
+				_, err := fmt.Fprint(w, ": ping\n\n")
+				if err == nil {
+					err = rc.Flush()
+				}
+				if err != nil {
+					log.Error("failed to send ping", sl.Err(err))
+					return
+				}
+			}
+		}
+	}
+}
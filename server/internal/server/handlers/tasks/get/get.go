@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
 	"github.com/go-chi/chi"
@@ -19,7 +20,7 @@ type Response struct {
 }
 
 type TasksGetter interface {
-	GetTasks() ([]models.Task, error)
+	GetActiveTasks() ([]models.Task, error)
 	GetBusTasks(int) ([]models.Task, error)
 }
 
@@ -33,11 +34,15 @@ func New(log *slog.Logger, taskGetter TasksGetter) http.HandlerFunc {
 		)
 
 		busID := chi.URLParam(r, "busID")
+		// Drivers only see the tasks of the bus of their current shift.
+		if user, _ := auth.FromContext(r.Context()); user.Role == models.RoleDriver {
+			busID = strconv.Itoa(user.BusID)
+		}
 		log.Debug("busID", busID)
 		if busID == "" {
 			log.Info("get all work tasks")
 
-			tasks, err := taskGetter.GetTasks()
+			tasks, err := taskGetter.GetActiveTasks()
 			if err != nil {
 				log.Error("failed to get tasks", sl.Err(err))
 				render.JSON(w, r, resp.Error("internal error"))
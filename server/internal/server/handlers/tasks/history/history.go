@@ -0,0 +1,55 @@
+package history
+
+import (
+	"net/http"
+	"strconv"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	History []models.TaskRecord `json:"history"`
+}
+
+type RecordsGetter interface {
+	GetTaskRecords(taskID int) ([]models.TaskRecord, error)
+}
+
+// New lists who changed the task and when, oldest change first. The history
+// of a deleted task is kept.
+func New(log *slog.Logger, recordsGetter RecordsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.history.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+		if err != nil {
+			log.Error("wrong taskID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong taskID format"))
+			return
+		}
+
+		records, err := recordsGetter.GetTaskRecords(taskID)
+		if err != nil {
+			log.Error("failed to get task history", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("task history found and submitted", slog.Int("taskID", taskID), slog.Int("records", len(records)))
+		render.JSON(w, r, Response{Response: resp.OK(), History: records})
+	}
+}
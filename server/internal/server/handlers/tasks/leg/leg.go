@@ -0,0 +1,87 @@
+package leg
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Task models.Task `json:"task"`
+}
+
+type LegMarker interface {
+	MarkLeg(taskID int, kind string, start bool, at time.Time, userID int, role string) (models.Task, error)
+}
+
+// New records that the driver started or finished a leg of the task.
+func New(log *slog.Logger, legMarker LegMarker, start bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.leg.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+		if err != nil {
+			log.Error("wrong taskID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong taskID format"))
+			return
+		}
+
+		kind := chi.URLParam(r, "kind")
+		switch kind {
+		case models.LegEmpty, models.LegBoarding, models.LegLoaded, models.LegUnloading, models.LegReturn:
+		default:
+			log.Error("wrong leg kind", slog.String("kind", kind))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong leg kind"))
+			return
+		}
+
+		user, _ := auth.FromContext(r.Context())
+		task, err := legMarker.MarkLeg(taskID, kind, start, time.Now(), user.ID, user.Role)
+		if errors.Is(err, taskstorage.ErrTaskNotFound) {
+			log.Error("task not found", slog.Int("taskID", taskID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("task not found"))
+			return
+		}
+		if errors.Is(err, taskstorage.ErrLegNotFound) {
+			log.Error("leg not found", slog.String("kind", kind))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("task has no such leg"))
+			return
+		}
+		if errors.Is(err, taskstorage.ErrLegOrder) {
+			log.Error("leg marked out of order", sl.Err(err))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.Error("leg is already marked or previous legs are not finished"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to mark leg", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("leg marked", slog.Int("taskID", taskID), slog.String("kind", kind), slog.Bool("start", start))
+		render.JSON(w, r, Response{Response: resp.OK(), Task: task})
+	}
+}
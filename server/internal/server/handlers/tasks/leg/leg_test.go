@@ -0,0 +1,68 @@
+package leg
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"github.com/go-chi/chi"
+	"golang.org/x/exp/slog"
+)
+
+// fakeMarker has task 1 with a boarding leg only and records the actor.
+type fakeMarker struct {
+	userID int
+	role   string
+}
+
+func (f *fakeMarker) MarkLeg(taskID int, kind string, start bool, at time.Time, userID int, role string) (models.Task, error) {
+	f.userID, f.role = userID, role
+	if taskID != 1 {
+		return models.Task{}, taskstorage.ErrTaskNotFound
+	}
+	if kind != models.LegBoarding {
+		return models.Task{}, taskstorage.ErrLegNotFound
+	}
+	return models.Task{Id: 1}, nil
+}
+
+func TestLeg(t *testing.T) {
+	marker := &fakeMarker{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router := chi.NewRouter()
+	router.Post("/tasks/{taskID}/legs/{kind}/start", New(log, marker, true))
+	driver := auth.User{ID: 2, Role: models.RoleDriver, BusID: 7}
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "marked", path: "/tasks/1/legs/boarding/start", want: http.StatusOK},
+		{name: "wrong taskID", path: "/tasks/one/legs/boarding/start", want: http.StatusBadRequest},
+		{name: "wrong kind", path: "/tasks/1/legs/flying/start", want: http.StatusBadRequest},
+		{name: "task not found", path: "/tasks/2/legs/boarding/start", want: http.StatusNotFound},
+		{name: "leg not found", path: "/tasks/1/legs/return/start", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.path, nil)
+			r = r.WithContext(auth.WithUser(r.Context(), driver))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	if marker.userID != driver.ID || marker.role != driver.Role {
+		t.Errorf("leg marked by %d %q, want %d %q", marker.userID, marker.role, driver.ID, driver.Role)
+	}
+}
@@ -0,0 +1,68 @@
+package list
+
+import (
+	"net/http"
+	"strconv"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Tasks []models.Task `json:"tasks"`
+}
+
+type TasksGetter interface {
+	GetActiveTasks() ([]models.Task, error)
+	GetBusTasks(int) ([]models.Task, error)
+}
+
+// New lists the active tasks, those of one bus with the busID parameter.
+// Drivers get the tasks of the bus of their current shift, none if they are
+// off shift.
+func New(log *slog.Logger, tasksGetter TasksGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, _ := auth.FromContext(r.Context())
+
+		var tasks []models.Task
+		var err error
+		if user.Role == models.RoleDriver {
+			if user.BusID != 0 {
+				tasks, err = tasksGetter.GetBusTasks(user.BusID)
+			}
+		} else if value := r.URL.Query().Get("busID"); value != "" {
+			busID, convErr := strconv.Atoi(value)
+			if convErr != nil {
+				log.Error("wrong busID format", sl.Err(convErr))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong busID format"))
+				return
+			}
+			tasks, err = tasksGetter.GetBusTasks(busID)
+		} else {
+			tasks, err = tasksGetter.GetActiveTasks()
+		}
+		if err != nil {
+			log.Error("failed to get tasks", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("tasks found and submitted", slog.Int("tasks", len(tasks)))
+		render.JSON(w, r, Response{Response: resp.OK(), Tasks: tasks})
+	}
+}
@@ -0,0 +1,58 @@
+package remove
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type TaskDeleter interface {
+	DeleteTask(taskID int, userID int, role string) error
+}
+
+func New(log *slog.Logger, taskDeleter TaskDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.remove.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+		if err != nil {
+			log.Error("wrong taskID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong taskID format"))
+			return
+		}
+
+		user, _ := auth.FromContext(r.Context())
+
+		err = taskDeleter.DeleteTask(taskID, user.ID, user.Role)
+		if errors.Is(err, taskstorage.ErrTaskNotFound) {
+			log.Error("task not found", slog.Int("taskID", taskID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete task", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("task deleted", slog.Int("taskID", taskID))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,63 @@
+package show
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/etag"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+type Response struct {
+	resp.Response
+	Task models.Task `json:"task"`
+}
+
+type TaskGetter interface {
+	GetTask(taskID int) (models.Task, error)
+}
+
+func New(log *slog.Logger, taskGetter TaskGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.show.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+		if err != nil {
+			log.Error("wrong taskID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong taskID format"))
+			return
+		}
+
+		task, err := taskGetter.GetTask(taskID)
+		if errors.Is(err, taskstorage.ErrTaskNotFound) {
+			log.Error("task not found", slog.Int("taskID", taskID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get task", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("task found and submitted", slog.Int("taskID", taskID))
+		w.Header().Set("ETag", etag.Format(task.Version))
+		render.JSON(w, r, Response{Response: resp.OK(), Task: task})
+	}
+}
@@ -0,0 +1,152 @@
+package socket
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/websocket"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	writeTimeout = 10 * time.Second
+	pingInterval = 30 * time.Second
+	pongTimeout  = 2 * pingInterval
+)
+
+type Subscriber interface {
+	Subscribe(busID int) (<-chan models.TaskEvent, func())
+}
+
+// New streams task events over a WebSocket as JSON messages, those of one
+// bus with the busID parameter. Messages from the client are ignored.
+// Browsers may only connect from the API origin or the allowed origins, see
+// checkOrigin.
+func New(log *slog.Logger, subscriber Subscriber, allowedOrigins []string) http.HandlerFunc {
+	upgrader := websocket.Upgrader{CheckOrigin: checkOrigin(allowedOrigins)}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.socket.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		busID := 0
+		if value := r.URL.Query().Get("busID"); value != "" {
+			var err error
+			busID, err = strconv.Atoi(value)
+			if err != nil || busID <= 0 {
+				log.Error("wrong busID format")
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong busID format"))
+				return
+			}
+		}
+
+		// Drivers only follow the bus of their current shift.
+		if user, _ := auth.FromContext(r.Context()); user.Role == models.RoleDriver {
+			if user.BusID == 0 {
+				log.Error("driver is off shift", slog.Int("driverID", user.ID))
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("no current shift"))
+				return
+			}
+			busID = user.BusID
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already answered the client.
+			log.Error("failed to upgrade connection", sl.Err(err))
+			return
+		}
+		defer conn.Close()
+
+		events, cancel := subscriber.Subscribe(busID)
+		defer cancel()
+
+		log.Info("task socket opened", slog.Int("busID", busID))
+
+		// Reading is needed to process pongs and to notice the client leaving.
+		closed := make(chan struct{})
+		conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(pingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-closed:
+				log.Info("task socket closed by client")
+				return
+			case event, ok := <-events:
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if !ok {
+					conn.WriteMessage(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseGoingAway, "stream closed"))
+					log.Info("task socket closed")
+					return
+				}
+				if err := conn.WriteJSON(event); err != nil {
+					log.Error("failed to send event", sl.Err(err))
+					return
+				}
+			case <-ping.C:
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					log.Error("failed to send ping", sl.Err(err))
+					return
+				}
+			}
+		}
+	}
+}
+
+// checkOrigin accepts the requests without the Origin header, which only
+// browsers send, the same origin and the allowed ones, e.g.
+// "https://dispatcher.example.com". The dispatcher app is served apart from
+// the API.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, o := range allowed {
+			if strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,218 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/etag"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/logger/sl"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+)
+
+// Request holds the fields to change, missing fields are left as they are.
+type Request struct {
+	Status     *string    `json:"status"`
+	TimeStart  *time.Time `json:"time start"`
+	BusID      *int       `json:"busID"`
+	Passengers *int       `json:"passengers"`
+}
+
+// Response carries the changed task, or the current one on a version conflict.
+type Response struct {
+	resp.Response
+	Task models.Task `json:"task"`
+}
+
+type TaskUpdater interface {
+	GetTask(taskID int) (models.Task, error)
+	UpdateTask(task models.Task, version int, userID int, role string) (models.Task, error)
+}
+
+type BusGetter interface {
+	GetBus(busID int) (models.Bus, error)
+}
+
+// New changes the task. With the If-Match header the task is only changed
+// if its ETag, the version, still matches; otherwise the answer is 409 with
+// the current task. Without the header the task must not change between
+// its reading here and the update.
+func New(log *slog.Logger, taskUpdater TaskUpdater, busGetter BusGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tasks.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+		if err != nil {
+			log.Error("wrong taskID format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong taskID format"))
+			return
+		}
+
+		version, err := etag.Version(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Error("wrong If-Match format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("wrong If-Match format"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("empty request"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		task, err := taskUpdater.GetTask(taskID)
+		if errors.Is(err, taskstorage.ErrTaskNotFound) {
+			log.Error("task not found", slog.Int("taskID", taskID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get task", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		user, _ := auth.FromContext(r.Context())
+		if !auth.CanChangeTask(user, task, req.fields()...) {
+			log.Error("task change is forbidden", slog.String("role", user.Role))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+			return
+		}
+
+		if req.Status != nil {
+			if !models.IsTaskStatus(*req.Status) {
+				log.Error("wrong status", slog.String("status", *req.Status))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong status"))
+				return
+			}
+			task.Status = *req.Status
+		}
+
+		// The end and the legs move with the start.
+		if req.TimeStart != nil {
+			task.Shift(req.TimeStart.Sub(task.TimeStart))
+		}
+
+		if req.Passengers != nil {
+			if *req.Passengers <= 0 {
+				log.Error("wrong passengers", slog.Int("passengers", *req.Passengers))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("passengers must be positive"))
+				return
+			}
+			task.Passengers = *req.Passengers
+		}
+
+		if req.BusID != nil {
+			_, err := busGetter.GetBus(*req.BusID)
+			if errors.Is(err, busstorage.ErrBusNotFound) {
+				log.Error("bus not found", slog.Int("busID", *req.BusID))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("bus not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get bus", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+			task.BusID = *req.BusID
+		}
+
+		if version == 0 {
+			version = task.Version
+		}
+
+		task, err = taskUpdater.UpdateTask(task, version, user.ID, user.Role)
+		if errors.Is(err, taskstorage.ErrVersionConflict) {
+			log.Error("task version conflict", slog.Int("taskID", taskID), slog.Int("version", version), slog.Int("current", task.Version))
+			w.Header().Set("ETag", etag.Format(task.Version))
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, Response{Response: resp.Error("task was changed, reload it"), Task: task})
+			return
+		}
+		var transition *taskstorage.StatusTransitionError
+		if errors.As(err, &transition) {
+			log.Error("illegal status transition", slog.String("from", transition.From), slog.String("to", transition.To))
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, resp.Error(fmt.Sprintf("cannot change status from %s to %s", transition.From, transition.To)))
+			return
+		}
+		if errors.Is(err, taskstorage.ErrTaskTime) {
+			log.Error("task does not end after its start", slog.Int("taskID", taskID))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("time end must be after time start"))
+			return
+		}
+		if errors.Is(err, taskstorage.ErrTaskNotFound) {
+			log.Error("task not found", slog.Int("taskID", taskID))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to update task", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+			return
+		}
+
+		log.Info("task updated", slog.Int("taskID", taskID), slog.Int("version", task.Version))
+		w.Header().Set("ETag", etag.Format(task.Version))
+		render.JSON(w, r, Response{Response: resp.OK(), Task: task})
+	}
+}
+
+// fields returns the names of the fields to change.
+func (req Request) fields() []string {
+	var fields []string
+	if req.Status != nil {
+		fields = append(fields, "status")
+	}
+	if req.TimeStart != nil {
+		fields = append(fields, "time")
+	}
+	if req.BusID != nil {
+		fields = append(fields, "busID")
+	}
+	if req.Passengers != nil {
+		fields = append(fields, "passengers")
+	}
+	return fields
+}
@@ -0,0 +1,217 @@
+package access
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	resp "github.com/GrishaSkurikhin/Aviahackathon/internal/lib/api/response"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type TokenParser interface {
+	Parse(token string) (auth.User, error)
+}
+
+type TaskGetter interface {
+	GetTask(taskID int) (models.Task, error)
+}
+
+type ShiftGetter interface {
+	CurrentShift(driverID int, at time.Time) (models.Shift, error)
+}
+
+type TicketRedeemer interface {
+	Redeem(ticket string) (auth.User, error)
+}
+
+// Authenticate puts the user of the session token into the request context.
+// The token is read from the header "Authorization: Bearer <token>".
+func Authenticate(tokens TokenParser) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error("unauthorized"))
+				return
+			}
+
+			user, err := tokens.Parse(token)
+			if err != nil {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error("invalid token"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// AuthenticateStream authenticates the requests opening event streams.
+// Browsers cannot set headers on them, so besides the session token the
+// single-use ticket from the ticket parameter is accepted.
+func AuthenticateStream(tokens TokenParser, tickets TicketRedeemer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withToken := Authenticate(tokens)(next)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ticket := r.URL.Query().Get(TicketParam)
+			if ticket == "" || r.Header.Get("Authorization") != "" {
+				withToken.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := tickets.Redeem(ticket)
+			if err != nil {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error("invalid ticket"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// TicketParam is the URL parameter with the ticket of an event stream.
+const TicketParam = "ticket"
+
+// HideTicket replaces the ticket in the request URI, so that the request
+// loggers after it do not write it out. The parsed URL keeps the ticket.
+func HideTicket(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get(TicketParam) != "" {
+			query.Set(TicketParam, "hidden")
+			uri := *r.URL
+			uri.RawQuery = query.Encode()
+
+			r = r.Clone(r.Context())
+			r.RequestURI = uri.RequestURI()
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// Shift sets the bus of a driver to the bus of the current shift of the
+// driver. A driver with no current shift has no bus: the tasks, the
+// location and the breaks of buses are closed to them. Other roles pass.
+func Shift(shifts ShiftGetter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			user, _ := auth.FromContext(r.Context())
+			if user.Role != models.RoleDriver {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			shift, err := shifts.CurrentShift(user.ID, time.Now())
+			if err != nil && !errors.Is(err, driverstorage.ErrShiftNotFound) {
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+			user.BusID = shift.BusID
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Require lets through the users with one of the roles.
+func Require(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			user, _ := auth.FromContext(r.Context())
+			for _, role := range roles {
+				if user.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// OwnBus lets drivers through only to the bus of their current shift from
+// the busID route parameter. Other roles pass.
+func OwnBus(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.FromContext(r.Context())
+		if user.Role == models.RoleDriver && (user.BusID == 0 || chi.URLParam(r, "busID") != strconv.Itoa(user.BusID)) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// OwnTask lets drivers through only to the tasks of the bus of their current
+// shift, the task is taken from the taskID route parameter. Other roles pass.
+func OwnTask(taskGetter TaskGetter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			user, _ := auth.FromContext(r.Context())
+			if user.Role != models.RoleDriver {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			taskID, err := strconv.Atoi(chi.URLParam(r, "taskID"))
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("wrong taskID format"))
+				return
+			}
+
+			task, err := taskGetter.GetTask(taskID)
+			if errors.Is(err, taskstorage.ErrTaskNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("task not found"))
+				return
+			}
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+				return
+			}
+
+			if user.BusID == 0 || task.BusID != user.BusID {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("forbidden"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,282 @@
+package access
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
+	"github.com/go-chi/chi"
+)
+
+var (
+	dispatcher = auth.User{ID: 1, Role: models.RoleDispatcher}
+	driver     = auth.User{ID: 2, Role: models.RoleDriver}
+)
+
+type fakeTokens map[string]auth.User
+
+func (f fakeTokens) Parse(token string) (auth.User, error) {
+	user, ok := f[token]
+	if !ok {
+		return auth.User{}, auth.ErrInvalidToken
+	}
+	return user, nil
+}
+
+type fakeTickets map[string]auth.User
+
+func (f fakeTickets) Redeem(ticket string) (auth.User, error) {
+	user, ok := f[ticket]
+	if !ok {
+		return auth.User{}, auth.ErrInvalidTicket
+	}
+	delete(f, ticket)
+	return user, nil
+}
+
+type fakeShifts map[int]models.Shift
+
+func (f fakeShifts) CurrentShift(driverID int, at time.Time) (models.Shift, error) {
+	shift, ok := f[driverID]
+	if !ok {
+		return models.Shift{}, driverstorage.ErrShiftNotFound
+	}
+	return shift, nil
+}
+
+type fakeTasks map[int]models.Task
+
+func (f fakeTasks) GetTask(taskID int) (models.Task, error) {
+	task, ok := f[taskID]
+	if !ok {
+		return models.Task{}, taskstorage.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// whoami answers with the role and the bus of the user in the context.
+func whoami(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+	w.Write([]byte(user.Role + "/" + strconv.Itoa(user.BusID)))
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestAuthenticate(t *testing.T) {
+	h := Authenticate(fakeTokens{"good": dispatcher})(http.HandlerFunc(whoami))
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		want   int
+	}{
+		{name: "token", header: "Bearer good", want: http.StatusOK},
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "empty token", header: "Bearer ", want: http.StatusUnauthorized},
+		{name: "other scheme", header: "Basic good", want: http.StatusUnauthorized},
+		{name: "invalid token", header: "Bearer bad", want: http.StatusUnauthorized},
+		{name: "token in query", query: "?access_token=good", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/tasks"+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if w := serve(h, r); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateStream(t *testing.T) {
+	tickets := fakeTickets{"once": driver}
+	h := AuthenticateStream(fakeTokens{"good": dispatcher}, tickets)(http.HandlerFunc(whoami))
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		want   int
+	}{
+		{name: "token", header: "Bearer good", want: http.StatusOK},
+		{name: "ticket", query: "?ticket=once", want: http.StatusOK},
+		{name: "used ticket", query: "?ticket=once", want: http.StatusUnauthorized},
+		{name: "unknown ticket", query: "?ticket=other", want: http.StatusUnauthorized},
+		{name: "token in query", query: "?access_token=good", want: http.StatusUnauthorized},
+		{name: "invalid token with ticket", header: "Bearer bad", query: "?ticket=other", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/tasks/events"+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if w := serve(h, r); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHideTicket(t *testing.T) {
+	var uri, ticket string
+	h := HideTicket(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uri = r.RequestURI
+		ticket = r.URL.Query().Get(TicketParam)
+	}))
+
+	r := httptest.NewRequest("GET", "/api/v1/tasks/events?busID=7&ticket=secret", nil)
+	serve(h, r)
+
+	if strings.Contains(uri, "secret") {
+		t.Errorf("RequestURI = %q, still has the ticket", uri)
+	}
+	if !strings.Contains(uri, "busID=7") {
+		t.Errorf("RequestURI = %q, lost the other parameters", uri)
+	}
+	if ticket != "secret" {
+		t.Errorf("ticket = %q, want %q", ticket, "secret")
+	}
+}
+
+func TestShift(t *testing.T) {
+	shifts := fakeShifts{driver.ID: {DriverID: driver.ID, BusID: 7}}
+	h := Shift(shifts)(http.HandlerFunc(whoami))
+
+	tests := []struct {
+		name string
+		user auth.User
+		want string
+	}{
+		{name: "driver on shift", user: driver, want: "driver/7"},
+		{name: "driver without shift", user: auth.User{ID: 3, Role: models.RoleDriver, BusID: 5}, want: "driver/0"},
+		{name: "dispatcher", user: dispatcher, want: "dispatcher/0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/tasks", nil)
+			r = r.WithContext(auth.WithUser(r.Context(), tt.user))
+			if got := serve(h, r).Body.String(); got != tt.want {
+				t.Errorf("user = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	h := Require(models.RoleDispatcher, models.RoleAdmin)(http.HandlerFunc(whoami))
+
+	tests := []struct {
+		name string
+		user auth.User
+		want int
+	}{
+		{name: "dispatcher", user: dispatcher, want: http.StatusOK},
+		{name: "admin", user: auth.User{ID: 4, Role: models.RoleAdmin}, want: http.StatusOK},
+		{name: "driver", user: driver, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/admin/replan", nil)
+			r = r.WithContext(auth.WithUser(r.Context(), tt.user))
+			if w := serve(h, r); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnBus(t *testing.T) {
+	router := chi.NewRouter()
+	router.With(OwnBus).Post("/buses/{busID}/location", whoami)
+
+	tests := []struct {
+		name string
+		user auth.User
+		bus  string
+		want int
+	}{
+		{name: "own bus", user: auth.User{ID: 2, Role: models.RoleDriver, BusID: 7}, bus: "7", want: http.StatusOK},
+		{name: "other bus", user: auth.User{ID: 2, Role: models.RoleDriver, BusID: 7}, bus: "8", want: http.StatusForbidden},
+		{name: "driver without shift", user: driver, bus: "0", want: http.StatusForbidden},
+		{name: "dispatcher", user: dispatcher, bus: "8", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/buses/"+tt.bus+"/location", nil)
+			r = r.WithContext(auth.WithUser(r.Context(), tt.user))
+			if w := serve(router, r); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnTask(t *testing.T) {
+	router := chi.NewRouter()
+	router.With(OwnTask(fakeTasks{1: {Id: 1, BusID: 7}})).Get("/tasks/{taskID}", whoami)
+
+	tests := []struct {
+		name string
+		user auth.User
+		task string
+		want int
+	}{
+		{name: "own task", user: auth.User{ID: 2, Role: models.RoleDriver, BusID: 7}, task: "1", want: http.StatusOK},
+		{name: "task of other bus", user: auth.User{ID: 2, Role: models.RoleDriver, BusID: 8}, task: "1", want: http.StatusForbidden},
+		{name: "driver without shift", user: driver, task: "1", want: http.StatusForbidden},
+		{name: "unknown task", user: auth.User{ID: 2, Role: models.RoleDriver, BusID: 7}, task: "2", want: http.StatusNotFound},
+		{name: "wrong taskID", user: auth.User{ID: 2, Role: models.RoleDriver, BusID: 7}, task: "one", want: http.StatusBadRequest},
+		{name: "dispatcher", user: dispatcher, task: "2", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/tasks/"+tt.task, nil)
+			r = r.WithContext(auth.WithUser(r.Context(), tt.user))
+			if w := serve(router, r); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestShiftError(t *testing.T) {
+	h := Shift(failingShifts{})(http.HandlerFunc(whoami))
+
+	r := httptest.NewRequest("GET", "/api/v1/tasks", nil)
+	r = r.WithContext(auth.WithUser(r.Context(), driver))
+	if w := serve(h, r); w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+type failingShifts struct{}
+
+func (failingShifts) CurrentShift(driverID int, at time.Time) (models.Shift, error) {
+	return models.Shift{}, errors.New("connection refused")
+}
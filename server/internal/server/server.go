@@ -5,10 +5,61 @@ import (
 	"fmt"
 	"net/http"
 
+	accountstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/account-storage/postgresql"
+	busstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/bus-storage/postgresql"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/config"
+	driverstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/driver-storage/postgresql"
+	flightstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/flight-storage/postgresql"
+	graphstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/graph-storage/postgresql"
+	incidentstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/incident-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/auth"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/lib/hub"
+	locationstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/location-storage/postgresql"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+	distancegraph "github.com/GrishaSkurikhin/Aviahackathon/internal/models/distance-graph"
+	accountcreate "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/accounts/create"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/auth/driver"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/auth/login"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/auth/ticket"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/breaks/end"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/breaks/start"
+	buslist "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/buses/list"
+	busshow "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/buses/show"
+	drivercreate "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/drivers/create"
+	driverlist "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/drivers/list"
+	driverpin "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/drivers/pin"
+	drivershow "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/drivers/show"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/flights/feed"
+	flightlist "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/flights/list"
+	flightshow "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/flights/show"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/graph/geojson"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/graph/reload"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/graph/restrict"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/incidents/list"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/incidents/report"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/incidents/resolve"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/locations/add"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/locations/history"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/schedule/replan"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/shifts/checkin"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/shifts/checkout"
+	shiftcreate "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/shifts/create"
+	shiftlist "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/shifts/list"
+	shiftremove "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/shifts/remove"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/change"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/create"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/events"
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/get"
+	taskhistory "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/history"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/leg"
+	tasklist "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/list"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/remove"
+	taskshow "github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/show"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/socket"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/handlers/tasks/update"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/middleware/access"
 	mwLogger "github.com/GrishaSkurikhin/Aviahackathon/internal/server/middleware/logger"
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/server/middleware/token"
 
 	taskstorage "github.com/GrishaSkurikhin/Aviahackathon/internal/task-storage/postgresql"
 	"github.com/go-chi/chi"
@@ -16,11 +67,18 @@ import (
 	"golang.org/x/exp/slog"
 )
 
+// Scheduler plans tasks and moves them between buses on request.
+type Scheduler interface {
+	start.Reassigner
+	replan.Planner
+}
+
 type server struct {
 	*http.Server
+	stop context.CancelFunc // stops the task event streams
 }
 
-func New(cfg *config.Config, log *slog.Logger) (*server, error) {
+func New(cfg *config.Config, log *slog.Logger, graph *distancegraph.Holder, sched Scheduler) (*server, error) {
 	const op = "server.New"
 
 	ts, err := taskstorage.New(cfg.TS.Host, cfg.TS.Port, cfg.TS.User, cfg.TS.Password, cfg.TS.DBname)
@@ -28,20 +86,176 @@ func New(cfg *config.Config, log *slog.Logger) (*server, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	bs, err := busstorage.New(cfg.BS.Host, cfg.BS.Port, cfg.BS.User, cfg.BS.Password, cfg.BS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	gs, err := graphstorage.New(cfg.GS.Host, cfg.GS.Port, cfg.GS.User, cfg.GS.Password, cfg.GS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ls, err := locationstorage.New(cfg.LS.Host, cfg.LS.Port, cfg.LS.User, cfg.LS.Password, cfg.LS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	is, err := incidentstorage.New(cfg.IS.Host, cfg.IS.Port, cfg.IS.User, cfg.IS.Password, cfg.IS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fs, err := flightstorage.New(cfg.FS.Host, cfg.FS.Port, cfg.FS.User, cfg.FS.Password, cfg.FS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	as, err := accountstorage.New(cfg.AS.Host, cfg.AS.Port, cfg.AS.User, cfg.AS.Password, cfg.AS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ds, err := driverstorage.New(cfg.DS.Host, cfg.DS.Port, cfg.DS.User, cfg.DS.Password, cfg.DS.DBname)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := auth.NewTokens(cfg.Auth.Secret, cfg.Auth.TokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tickets := auth.NewTickets(cfg.Auth.TicketTTL)
+
+	// Task changes made by anyone, the scheduler included, reach the
+	// streaming clients through the notifications of the tasks table.
+	ctx, stop := context.WithCancel(context.Background())
+	changes, err := ts.Changes(ctx)
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	tasksHub := hub.New()
+	go tasksHub.Run(ctx, log, changes, ts)
+
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(access.HideTicket)
 	router.Use(middleware.Logger)
 	router.Use(mwLogger.New(log))
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
-	router.Route("/get-tasks", func(r chi.Router) {
-		r.Get("/", get.New(log, ts))
+	authenticate := access.Authenticate(tokens)
+	shift := access.Shift(ds)
+	staff := access.Require(models.RoleDispatcher, models.RoleAdmin)
+	ownTask := access.OwnTask(ts)
+
+	// The first version of the API: resources with HTTP status codes.
+	router.Route("/api/v1", func(r chi.Router) {
+		r.Route("/auth", func(r chi.Router) {
+			r.Post("/login", login.New(log, as, tokens))
+			r.Post("/driver", driver.New(log, ds, ds, tokens))
+			r.With(authenticate).Post("/ticket", ticket.New(log, tickets))
+		})
+
+		// Browsers open the event streams with a ticket instead of the token.
+		r.Group(func(r chi.Router) {
+			r.Use(access.AuthenticateStream(tokens, tickets))
+			r.Use(shift)
+
+			r.Get("/tasks/events", events.New(log, tasksHub))
+			r.Get("/tasks/ws", socket.New(log, tasksHub, cfg.AllowedOrigins))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(authenticate)
+			r.Use(shift)
+
+			r.Route("/tasks", func(r chi.Router) {
+				r.Get("/", tasklist.New(log, ts))
+				r.With(staff).Post("/", create.New(log, ts, bs, fs, graph))
+				r.With(ownTask).Get("/{taskID}", taskshow.New(log, ts))
+				r.With(ownTask).Patch("/{taskID}", update.New(log, ts, bs))
+				r.With(staff).Delete("/{taskID}", remove.New(log, ts))
+				r.With(ownTask).Get("/{taskID}/history", taskhistory.New(log, ts))
+			})
+
+			r.Route("/buses", func(r chi.Router) {
+				r.Use(staff)
+				r.Get("/", buslist.New(log, bs))
+				r.Get("/{busID}", busshow.New(log, bs))
+			})
+
+			r.Route("/flights", func(r chi.Router) {
+				r.Use(staff)
+				r.Get("/", flightlist.New(log, fs))
+				r.Get("/{flightID}", flightshow.New(log, fs))
+			})
+
+			r.Route("/drivers", func(r chi.Router) {
+				r.Use(staff)
+				r.Get("/", driverlist.New(log, ds))
+				r.Post("/", drivercreate.New(log, ds))
+				r.Get("/{driverID}", drivershow.New(log, ds))
+				r.Post("/{driverID}/pin", driverpin.New(log, ds))
+			})
+
+			r.Route("/shifts", func(r chi.Router) {
+				r.Get("/", shiftlist.New(log, ds))
+				r.With(staff).Post("/", shiftcreate.New(log, ds, bs))
+				r.With(staff).Delete("/{shiftID}", shiftremove.New(log, ds))
+				r.With(access.Require(models.RoleDriver)).Post("/check-in", checkin.New(log, ds))
+				r.With(access.Require(models.RoleDriver)).Post("/check-out", checkout.New(log, ds))
+			})
+
+			r.With(access.Require(models.RoleAdmin)).Post("/accounts", accountcreate.New(log, as))
+		})
+	})
+
+	router.Route("/aodb", func(r chi.Router) {
+		r.Use(token.New(cfg.AODB.Token))
+		r.Post("/flights", feed.New(log, fs, graph))
 	})
 
-	router.Route("/change-task", func(r chi.Router) {
-		r.Post("/", change.New(log, ts))
+	// The endpoints below predate /api/v1 and are kept for the existing clients.
+	router.Group(func(r chi.Router) {
+		r.Use(authenticate)
+		r.Use(shift)
+
+		r.Get("/get-tasks", get.New(log, ts))
+		r.Post("/change-task", change.New(log, ts))
+
+		r.Route("/tasks/{taskID}/legs/{kind}", func(r chi.Router) {
+			r.Use(ownTask)
+			r.Post("/start", leg.New(log, ts, true))
+			r.Post("/end", leg.New(log, ts, false))
+		})
+
+		r.Route("/buses/{busID}", func(r chi.Router) {
+			r.Use(access.OwnBus)
+			r.Post("/location", add.New(log, ls, graph))
+			r.With(staff).Get("/locations", history.New(log, ls))
+			r.Post("/break", start.New(log, bs, sched))
+			r.Post("/break/end", end.New(log, bs))
+		})
+
+		r.Route("/incidents", func(r chi.Router) {
+			r.With(staff).Get("/", list.New(log, is))
+			r.Post("/", report.New(log, is, bs, sched))
+			r.With(staff).Post("/{incidentID}/resolve", resolve.New(log, is, bs))
+		})
+
+		r.With(staff).Get("/geojson", geojson.New(log, graph, ts))
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(staff)
+			r.Post("/reload-graph", reload.New(log, graph))
+			r.Post("/restrictions", restrict.New(log, gs, graph))
+			r.Post("/replan", replan.New(log, sched))
+		})
 	})
 
 	srv := &http.Server{
@@ -52,7 +266,7 @@ func New(cfg *config.Config, log *slog.Logger) (*server, error) {
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
 
-	return &server{srv}, nil
+	return &server{Server: srv, stop: stop}, nil
 }
 
 func (srv *server) Start() error {
@@ -66,6 +280,8 @@ func (srv *server) Start() error {
 
 func (srv *server) Close(ctx *context.Context) error {
 	const op = "server.Close"
+	// Shutdown waits for the open streams, they end with the hub.
+	srv.stop()
 	err := srv.Shutdown(*ctx)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
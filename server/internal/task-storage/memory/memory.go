@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+// TaskStorage keeps tasks in memory.
+type TaskStorage struct {
+	mu     sync.RWMutex
+	tasks  []models.Task
+	nextID int
+}
+
+func New() *TaskStorage {
+	return &TaskStorage{nextID: 1}
+}
+
+func (s *TaskStorage) GetTasks() ([]models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]models.Task, len(s.tasks))
+	copy(tasks, s.tasks)
+
+	return tasks, nil
+}
+
+func (s *TaskStorage) AddTasks(tasks []models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, task := range tasks {
+		task.Id = s.nextID
+		task.Version = 1
+		s.nextID++
+		s.tasks = append(s.tasks, task)
+	}
+
+	return nil
+}
+
+// ApplyDiff deletes the removed tasks, rewrites the moved ones and adds the
+// added ones at once. As in the postgresql storage, stored tasks keep their
+// status, and removed and moved tasks of another version are left as they
+// are and their ids returned.
+func (s *TaskStorage) ApplyDiff(removed []models.Task, moved []models.Task, added []models.Task) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var skipped []int
+	stored := make(map[int]int, len(s.tasks))
+	for i, task := range s.tasks {
+		stored[task.Id] = i
+	}
+	// current reports whether the task is stored with the same version.
+	current := func(task models.Task) bool {
+		i, ok := stored[task.Id]
+		return ok && s.tasks[i].Version == task.Version
+	}
+
+	for _, task := range moved {
+		if !current(task) {
+			skipped = append(skipped, task.Id)
+			continue
+		}
+		i := stored[task.Id]
+		task.Status = s.tasks[i].Status
+		task.Version++
+		s.tasks[i] = task
+	}
+
+	deleted := make(map[int]bool, len(removed))
+	for _, task := range removed {
+		if !current(task) {
+			skipped = append(skipped, task.Id)
+			continue
+		}
+		deleted[task.Id] = true
+	}
+
+	kept := s.tasks[:0]
+	for _, task := range s.tasks {
+		if !deleted[task.Id] {
+			kept = append(kept, task)
+		}
+	}
+	s.tasks = kept
+
+	for _, task := range added {
+		task.Id = s.nextID
+		task.Version = 1
+		s.nextID++
+		s.tasks = append(s.tasks, task)
+	}
+
+	return skipped, nil
+}
@@ -1,16 +1,55 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+var (
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrLegNotFound      = errors.New("leg not found")
+	ErrLegOrder         = errors.New("leg cannot be marked")
+	ErrStatusTransition = errors.New("illegal status transition")
+	ErrVersionConflict  = errors.New("task version conflict")
+	ErrTaskTime         = errors.New("task must end after its start")
+)
+
+// StatusTransitionError is ErrStatusTransition with the current status of
+// the task and the rejected one.
+type StatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("%s: from %q to %q", ErrStatusTransition, e.From, e.To)
+}
+
+func (e *StatusTransitionError) Unwrap() error {
+	return ErrStatusTransition
+}
+
+// changesChannel is the channel the tasks table triggers notify,
+// see migrations/014_tasks_notify.sql.
+const changesChannel = "task_changes"
+
+// taskColumns are the columns scanned by scanTask.
+const taskColumns = "id, bus_id, flight_id, passengers, time_start, time_end, status, pickup, dropoff, route, legs, version"
+
+// activeCondition selects the tasks that are not over.
+const activeCondition = "status NOT IN ('" + models.TaskStatusComplete + "', '" + models.TaskStatusCancelled + "')"
+
 type TaskStorage struct {
-	db *sql.DB
+	db   *sql.DB
+	info string
 }
 
 func New(host, port, user, password, dbname string) (*TaskStorage, error) {
@@ -22,13 +61,16 @@ func New(host, port, user, password, dbname string) (*TaskStorage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return &TaskStorage{db: db}, nil
+	return &TaskStorage{db: db, info: info}, nil
 }
 
+// GetTasks returns the tasks of the plan: all but the cancelled ones. The
+// complete tasks are there for the scheduler to count the passengers they
+// carried.
 func (s *TaskStorage) GetTasks() ([]models.Task, error) {
 	const op = "taskstorage.postgresql.GetTasks"
 
-	stmt, err := s.db.Prepare("SELECT * FROM tasks WHERE status != 'done'")
+	stmt, err := s.db.Prepare("SELECT " + taskColumns + " FROM tasks WHERE status != '" + models.TaskStatusCancelled + "'")
 	if err != nil {
 		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
@@ -42,9 +84,35 @@ func (s *TaskStorage) GetTasks() ([]models.Task, error) {
 
 	var tasks []models.Task
 	for rows.Next() {
-		var task models.Task
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// GetActiveTasks returns the tasks that are neither complete nor cancelled.
+func (s *TaskStorage) GetActiveTasks() ([]models.Task, error) {
+	const op = "taskstorage.postgresql.GetActiveTasks"
 
-		err := rows.Scan(&task.Id, &task.BusID, &task.FlightID, &task.TimeStart, &task.TimeEnd, &task.Status)
+	stmt, err := s.db.Prepare("SELECT " + taskColumns + " FROM tasks WHERE " + activeCondition)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
 		}
@@ -54,16 +122,37 @@ func (s *TaskStorage) GetTasks() ([]models.Task, error) {
 	return tasks, nil
 }
 
-func (s *TaskStorage) GetBusTasks(driverID int) ([]models.Task, error) {
+func (s *TaskStorage) GetTask(taskID int) (models.Task, error) {
+	const op = "taskstorage.postgresql.GetTask"
+
+	stmt, err := s.db.Prepare("SELECT " + taskColumns + " FROM tasks WHERE id = $1")
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	task, err := scanTask(stmt.QueryRow(taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, fmt.Errorf("%s: %w", op, ErrTaskNotFound)
+	}
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// GetBusTasks returns the active tasks of the bus.
+func (s *TaskStorage) GetBusTasks(busID int) ([]models.Task, error) {
 	const op = "taskstorage.postgresql.GetBusTasks"
 
-	stmt, err := s.db.Prepare("SELECT * FROM tasks WHERE status != 'done' AND bus_id = $1")
+	stmt, err := s.db.Prepare("SELECT " + taskColumns + " FROM tasks WHERE " + activeCondition + " AND bus_id = $1")
 	if err != nil {
 		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(driverID)
+	rows, err := stmt.Query(busID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
 	}
@@ -71,9 +160,7 @@ func (s *TaskStorage) GetBusTasks(driverID int) ([]models.Task, error) {
 
 	var tasks []models.Task
 	for rows.Next() {
-		var task models.Task
-
-		err := rows.Scan(&task.Id, &task.BusID, &task.FlightID, &task.TimeStart, &task.TimeEnd, &task.Status)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
 		}
@@ -83,68 +170,136 @@ func (s *TaskStorage) GetBusTasks(driverID int) ([]models.Task, error) {
 	return tasks, nil
 }
 
-func (s *TaskStorage) ChangeTaskStatus(taskID int, newStatus string) error {
+// ChangeTaskStatus moves the task to the status on behalf of the user and
+// returns the changed task. Transitions not allowed by
+// models.CanChangeTaskStatus are rejected with ErrStatusTransition. A
+// version other than zero must be the version of the task, otherwise the
+// current task is returned with ErrVersionConflict.
+func (s *TaskStorage) ChangeTaskStatus(taskID int, newStatus string, version int, userID int, role string) (models.Task, error) {
 	const op = "taskstorage.postgresql.ChangeTaskStatus"
 
-	stmt, err := s.db.Prepare("UPDATE tasks SET status = $1 WHERE id = $2")
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("%s: prepare statement: %w", op, err)
+		return models.Task{}, fmt.Errorf("%s: begin transaction: %w", op, err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(newStatus, taskID)
+	current, err := lockTask(tx, taskID, version)
 	if err != nil {
-		return fmt.Errorf("%s: execute statement: %w", op, err)
+		return current, fmt.Errorf("%s: %w", op, err)
+	}
+	if !models.CanChangeTaskStatus(current.Status, newStatus) {
+		return models.Task{}, fmt.Errorf("%s: %w", op, &StatusTransitionError{From: current.Status, To: newStatus})
 	}
 
-	return nil
+	if err := actAs(tx, userID, role); err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task, err := scanTask(tx.QueryRow("UPDATE tasks SET status = $1 WHERE id = $2 RETURNING "+taskColumns, newStatus, taskID))
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: update status: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return task, nil
 }
 
-func (s *TaskStorage) ChangeTaskTime(taskID int, newTime time.Time) error {
+// ChangeTaskTime moves the task to start at the time on behalf of the user,
+// its end and legs are shifted with it, see models.Task.Shift. A task that
+// does not end after its start gives ErrTaskTime. The version is checked as
+// in ChangeTaskStatus.
+func (s *TaskStorage) ChangeTaskTime(taskID int, newTime time.Time, version int, userID int, role string) (models.Task, error) {
 	const op = "taskstorage.postgresql.ChangeTaskTime"
 
-	stmt, err := s.db.Prepare("UPDATE tasks SET time_start = $1 WHERE id = $2")
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("%s: prepare statement: %w", op, err)
+		return models.Task{}, fmt.Errorf("%s: begin transaction: %w", op, err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(newTime.Format("2006-01-02 15:04:05"), taskID)
+	current, err := lockTask(tx, taskID, version)
 	if err != nil {
-		return fmt.Errorf("%s: execute statement: %w", op, err)
+		return current, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	if err := actAs(tx, userID, role); err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	current.Shift(newTime.Sub(current.TimeStart))
+	if !current.TimeEnd.After(current.TimeStart) {
+		return models.Task{}, fmt.Errorf("%s: %w", op, ErrTaskTime)
+	}
+	legs, err := json.Marshal(current.Legs)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: marshal legs: %w", op, err)
+	}
+
+	task, err := scanTask(tx.QueryRow("UPDATE tasks SET time_start = $1, time_end = $2, legs = $3 WHERE id = $4 RETURNING "+taskColumns,
+		current.TimeStart.Format("2006-01-02 15:04:05"), current.TimeEnd.Format("2006-01-02 15:04:05"), legs, taskID))
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return task, nil
 }
 
-func (s *TaskStorage) ChangeTaskBus(taskID int, newBusID int) error {
+// ChangeTaskBus moves the task to the bus on behalf of the user, the
+// version is checked as in ChangeTaskStatus.
+func (s *TaskStorage) ChangeTaskBus(taskID int, newBusID int, version int, userID int, role string) (models.Task, error) {
 	const op = "taskstorage.postgresql.ChangeTaskBus"
 
-	stmt, err := s.db.Prepare("UPDATE tasks SET bus_id = $1 WHERE id = $2")
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("%s: prepare statement: %w", op, err)
+		return models.Task{}, fmt.Errorf("%s: begin transaction: %w", op, err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(newBusID, taskID)
+	current, err := lockTask(tx, taskID, version)
 	if err != nil {
-		return fmt.Errorf("%s: execute statement: %w", op, err)
+		return current, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	if err := actAs(tx, userID, role); err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task, err := scanTask(tx.QueryRow("UPDATE tasks SET bus_id = $1 WHERE id = $2 RETURNING "+taskColumns, newBusID, taskID))
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return task, nil
 }
 
 func (s *TaskStorage) AddTasks(tasks []models.Task) error {
 	const op = "taskstorage.postgresql.AddTasks"
 
-	stmt, err := s.db.Prepare("INSERT INTO tasks (bus_id, flight_id, time_start, time_end, status) VALUES ($1, $2, $3, $4, %5)")
+	stmt, err := s.db.Prepare("INSERT INTO tasks (bus_id, flight_id, passengers, time_start, time_end, status, pickup, dropoff, route, legs) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)")
 	if err != nil {
 		return fmt.Errorf("%s: prepare statement: %w", op, err)
 	}
 	defer stmt.Close()
 
 	for _, task := range tasks {
-		_, err := stmt.Exec(task.BusID, task.FlightID, task.TimeStart, task.TimeEnd, task.Status)
+		legs, err := json.Marshal(task.Legs)
+		if err != nil {
+			return fmt.Errorf("%s: marshal legs: %w", op, err)
+		}
+
+		_, err = stmt.Exec(task.BusID, task.FlightID, task.Passengers, task.TimeStart, task.TimeEnd, task.Status, task.From, task.To, pq.Array(task.Route), legs)
 		if err != nil {
 			return fmt.Errorf("%s: execute statement: %w", op, err)
 		}
@@ -152,3 +307,426 @@ func (s *TaskStorage) AddTasks(tasks []models.Task) error {
 
 	return nil
 }
+
+// ApplyDiff writes a planning cycle in one transaction: deletes the removed
+// tasks, rewrites bus, passengers, times, points, route and legs of the
+// moved ones and stores the added ones. The status of a stored task is only
+// changed by drivers and dispatchers, within models.CanChangeTaskStatus.
+// Removed and moved tasks changed by anyone since the scheduler read them,
+// that is of another version, are left as they are and their ids returned.
+// Nothing is written if any step fails.
+func (s *TaskStorage) ApplyDiff(removed []models.Task, moved []models.Task, added []models.Task) ([]int, error) {
+	const op = "taskstorage.postgresql.ApplyDiff"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var skipped []int
+	// written records the task as skipped if the statement changed no row.
+	written := func(task models.Task, res sql.Result) error {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			skipped = append(skipped, task.Id)
+		}
+		return nil
+	}
+
+	deleteStmt, err := tx.Prepare("DELETE FROM tasks WHERE id = $1 AND version = $2")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer deleteStmt.Close()
+
+	for _, task := range removed {
+		res, err := deleteStmt.Exec(task.Id, task.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: delete task: %w", op, err)
+		}
+		if err := written(task, res); err != nil {
+			return nil, fmt.Errorf("%s: rows affected: %w", op, err)
+		}
+	}
+
+	updateStmt, err := tx.Prepare("UPDATE tasks SET bus_id = $1, passengers = $2, time_start = $3, time_end = $4, pickup = $5, dropoff = $6, route = $7, legs = $8 WHERE id = $9 AND version = $10")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer updateStmt.Close()
+
+	for _, task := range moved {
+		legs, err := json.Marshal(task.Legs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: marshal legs: %w", op, err)
+		}
+
+		res, err := updateStmt.Exec(task.BusID, task.Passengers, task.TimeStart, task.TimeEnd, task.From, task.To, pq.Array(task.Route), legs, task.Id, task.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: update task: %w", op, err)
+		}
+		if err := written(task, res); err != nil {
+			return nil, fmt.Errorf("%s: rows affected: %w", op, err)
+		}
+	}
+
+	insertStmt, err := tx.Prepare("INSERT INTO tasks (bus_id, flight_id, passengers, time_start, time_end, status, pickup, dropoff, route, legs) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)")
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer insertStmt.Close()
+
+	for _, task := range added {
+		legs, err := json.Marshal(task.Legs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: marshal legs: %w", op, err)
+		}
+
+		_, err = insertStmt.Exec(task.BusID, task.FlightID, task.Passengers, task.TimeStart, task.TimeEnd, task.Status, task.From, task.To, pq.Array(task.Route), legs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: insert task: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return skipped, nil
+}
+
+// AddTask stores the task on behalf of the user and returns it with its id.
+func (s *TaskStorage) AddTask(task models.Task, userID int, role string) (models.Task, error) {
+	const op = "taskstorage.postgresql.AddTask"
+
+	legs, err := json.Marshal(task.Legs)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: marshal legs: %w", op, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := actAs(tx, userID, role); err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow("INSERT INTO tasks (bus_id, flight_id, passengers, time_start, time_end, status, pickup, dropoff, route, legs) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, version",
+		task.BusID, task.FlightID, task.Passengers, task.TimeStart, task.TimeEnd, task.Status, task.From, task.To, pq.Array(task.Route), legs).Scan(&task.Id, &task.Version)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// UpdateTask rewrites bus, passengers, times, legs and status of the task on
+// behalf of the user and returns the changed task. A status change not
+// allowed by models.CanChangeTaskStatus is rejected with ErrStatusTransition,
+// a task that does not end after its start with ErrTaskTime.
+// The version is checked as in ChangeTaskStatus.
+func (s *TaskStorage) UpdateTask(task models.Task, version int, userID int, role string) (models.Task, error) {
+	const op = "taskstorage.postgresql.UpdateTask"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	current, err := lockTask(tx, task.Id, version)
+	if err != nil {
+		return current, fmt.Errorf("%s: %w", op, err)
+	}
+	if task.Status != current.Status && !models.CanChangeTaskStatus(current.Status, task.Status) {
+		return models.Task{}, fmt.Errorf("%s: %w", op, &StatusTransitionError{From: current.Status, To: task.Status})
+	}
+	if !task.TimeEnd.After(task.TimeStart) {
+		return models.Task{}, fmt.Errorf("%s: %w", op, ErrTaskTime)
+	}
+
+	if err := actAs(tx, userID, role); err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	legs, err := json.Marshal(task.Legs)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: marshal legs: %w", op, err)
+	}
+
+	task, err = scanTask(tx.QueryRow(`UPDATE tasks SET bus_id = $1, passengers = $2, time_start = $3, time_end = $4, legs = $5, status = $6
+		WHERE id = $7 RETURNING `+taskColumns,
+		task.BusID, task.Passengers, task.TimeStart, task.TimeEnd, legs, task.Status, task.Id))
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: update task: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// DeleteTask deletes the task on behalf of the user.
+func (s *TaskStorage) DeleteTask(taskID int, userID int, role string) error {
+	const op = "taskstorage.postgresql.DeleteTask"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := actAs(tx, userID, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.Exec("DELETE FROM tasks WHERE id = $1", taskID)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%s: %w", op, ErrTaskNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetTaskRecords returns the history of the task, oldest change first.
+func (s *TaskStorage) GetTaskRecords(taskID int) ([]models.TaskRecord, error) {
+	const op = "taskstorage.postgresql.GetTaskRecords"
+
+	stmt, err := s.db.Prepare(`SELECT id, task_id, time, COALESCE(user_id, 0), role, field, old_value, new_value
+		FROM task_events WHERE task_id = $1 ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	var records []models.TaskRecord
+	for rows.Next() {
+		var record models.TaskRecord
+
+		err := rows.Scan(&record.Id, &record.TaskID, &record.Time, &record.UserID, &record.Role, &record.Field,
+			&record.OldValue, &record.NewValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan statement: %w", op, err)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// MarkLeg records on behalf of the user the actual start or end of the
+// first leg of the kind and returns the updated task. Marks out of order are
+// rejected with ErrLegOrder, see markLeg.
+func (s *TaskStorage) MarkLeg(taskID int, kind string, start bool, at time.Time, userID int, role string) (models.Task, error) {
+	const op = "taskstorage.postgresql.MarkLeg"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	task, err := lockTask(tx, taskID, 0)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := markLeg(&task, kind, start, at); err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := actAs(tx, userID, role); err != nil {
+		return models.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	legs, err := json.Marshal(task.Legs)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: marshal legs: %w", op, err)
+	}
+
+	err = tx.QueryRow("UPDATE tasks SET legs = $1 WHERE id = $2 RETURNING version", legs, taskID).Scan(&task.Version)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("%s: update legs: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, fmt.Errorf("%s: commit transaction: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// markLeg records the actual start or end of the first leg of the kind. A
+// leg starts once all legs before it have ended, not earlier than the last
+// of them, and ends once after its start. Marking a leg that is already
+// started or ended again is rejected.
+func markLeg(task *models.Task, kind string, start bool, at time.Time) error {
+	index := -1
+	for i := range task.Legs {
+		if task.Legs[i].Kind == kind {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return ErrLegNotFound
+	}
+	leg := &task.Legs[index]
+
+	if !start {
+		switch {
+		case leg.ActualStart == nil:
+			return fmt.Errorf("%w: %s has not started", ErrLegOrder, kind)
+		case leg.ActualEnd != nil:
+			return fmt.Errorf("%w: %s has already ended", ErrLegOrder, kind)
+		case at.Before(*leg.ActualStart):
+			return fmt.Errorf("%w: %s cannot end before its start", ErrLegOrder, kind)
+		}
+		leg.ActualEnd = &at
+		return nil
+	}
+
+	if leg.ActualStart != nil {
+		return fmt.Errorf("%w: %s has already started", ErrLegOrder, kind)
+	}
+	for _, before := range task.Legs[:index] {
+		if before.ActualEnd == nil {
+			return fmt.Errorf("%w: %s has not ended", ErrLegOrder, before.Kind)
+		}
+		if at.Before(*before.ActualEnd) {
+			return fmt.Errorf("%w: %s cannot start before %s has ended", ErrLegOrder, kind, before.Kind)
+		}
+	}
+	leg.ActualStart = &at
+	return nil
+}
+
+// Changes listens for changes of the tasks table until the context is done.
+// Notifications sent while the connection is being restored are lost.
+func (s *TaskStorage) Changes(ctx context.Context) (<-chan models.TaskChange, error) {
+	const op = "taskstorage.postgresql.Changes"
+
+	listener := pq.NewListener(s.info, 10*time.Second, time.Minute, nil)
+	err := listener.Listen(changesChannel)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("%s: listen: %w", op, err)
+	}
+
+	changes := make(chan models.TaskChange)
+	go func() {
+		defer close(changes)
+		defer listener.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				// nil is sent after the connection was restored.
+				if n == nil {
+					continue
+				}
+
+				var change models.TaskChange
+				if err := json.Unmarshal([]byte(n.Extra), &change); err != nil {
+					continue
+				}
+
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			case <-time.After(90 * time.Second):
+				go listener.Ping()
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// lockTask selects the task for update in the transaction. A version other
+// than zero must be the version of the task, otherwise the current task is
+// returned with ErrVersionConflict.
+func lockTask(tx *sql.Tx, taskID int, version int) (models.Task, error) {
+	task, err := scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1 FOR UPDATE", taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, ErrTaskNotFound
+	}
+	if err != nil {
+		return models.Task{}, fmt.Errorf("select task: %w", err)
+	}
+	if version != 0 && task.Version != version {
+		return task, ErrVersionConflict
+	}
+	return task, nil
+}
+
+// actAs makes the changes of the transaction on behalf of the user for the
+// task history, see migrations/017_task_events.sql. Changes made outside
+// such transactions are recorded as made by the scheduler.
+func actAs(tx *sql.Tx, userID int, role string) error {
+	_, err := tx.Exec("SELECT set_config('tasks.user_id', $1, true), set_config('tasks.role', $2, true)",
+		strconv.Itoa(userID), role)
+	if err != nil {
+		return fmt.Errorf("set actor: %w", err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask reads a task selected with taskColumns.
+func scanTask(row scanner) (models.Task, error) {
+	var task models.Task
+	var legs []byte
+
+	err := row.Scan(&task.Id, &task.BusID, &task.FlightID, &task.Passengers, &task.TimeStart, &task.TimeEnd,
+		&task.Status, &task.From, &task.To, pq.Array(&task.Route), &legs, &task.Version)
+	if err != nil {
+		return task, err
+	}
+
+	if len(legs) > 0 {
+		if err := json.Unmarshal(legs, &task.Legs); err != nil {
+			return task, fmt.Errorf("unmarshal legs: %w", err)
+		}
+	}
+
+	return task, nil
+}
@@ -0,0 +1,145 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GrishaSkurikhin/Aviahackathon/internal/models"
+)
+
+func TestMarkLeg(t *testing.T) {
+	base := time.Date(2023, 10, 29, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := base.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	task := func(legs ...models.Leg) models.Task {
+		return models.Task{Id: 1, Legs: legs}
+	}
+	leg := func(kind string, start, end *time.Time) models.Leg {
+		return models.Leg{Kind: kind, ActualStart: start, ActualEnd: end}
+	}
+
+	tests := []struct {
+		name    string
+		task    models.Task
+		kind    string
+		start   bool
+		at      time.Time
+		wantErr error
+	}{
+		{
+			name:  "start first leg",
+			task:  task(leg(models.LegEmpty, nil, nil), leg(models.LegBoarding, nil, nil)),
+			kind:  models.LegEmpty,
+			start: true,
+			at:    *at(0),
+		},
+		{
+			name: "end started leg",
+			task: task(leg(models.LegEmpty, at(0), nil)),
+			kind: models.LegEmpty,
+			at:   *at(5),
+		},
+		{
+			name:  "start after previous leg ended",
+			task:  task(leg(models.LegEmpty, at(0), at(5)), leg(models.LegBoarding, nil, nil)),
+			kind:  models.LegBoarding,
+			start: true,
+			at:    *at(5),
+		},
+		{
+			name:    "no such leg",
+			task:    task(leg(models.LegEmpty, nil, nil)),
+			kind:    models.LegReturn,
+			start:   true,
+			at:      *at(0),
+			wantErr: ErrLegNotFound,
+		},
+		{
+			name:    "start twice",
+			task:    task(leg(models.LegEmpty, at(0), nil)),
+			kind:    models.LegEmpty,
+			start:   true,
+			at:      *at(1),
+			wantErr: ErrLegOrder,
+		},
+		{
+			name:    "end twice",
+			task:    task(leg(models.LegEmpty, at(0), at(5))),
+			kind:    models.LegEmpty,
+			at:      *at(6),
+			wantErr: ErrLegOrder,
+		},
+		{
+			name:    "end before start",
+			task:    task(leg(models.LegEmpty, nil, nil)),
+			kind:    models.LegEmpty,
+			at:      *at(5),
+			wantErr: ErrLegOrder,
+		},
+		{
+			name:    "end earlier than the start time",
+			task:    task(leg(models.LegEmpty, at(5), nil)),
+			kind:    models.LegEmpty,
+			at:      *at(1),
+			wantErr: ErrLegOrder,
+		},
+		{
+			name:    "start before previous leg ended",
+			task:    task(leg(models.LegEmpty, at(0), nil), leg(models.LegBoarding, nil, nil)),
+			kind:    models.LegBoarding,
+			start:   true,
+			at:      *at(5),
+			wantErr: ErrLegOrder,
+		},
+		{
+			name:    "start earlier than previous leg end",
+			task:    task(leg(models.LegEmpty, at(0), at(5)), leg(models.LegBoarding, nil, nil)),
+			kind:    models.LegBoarding,
+			start:   true,
+			at:      *at(3),
+			wantErr: ErrLegOrder,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			err := markLeg(&task, tt.kind, tt.start, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("markLeg() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			leg, _ := task.Leg(tt.kind)
+			got := leg.ActualEnd
+			if tt.start {
+				got = leg.ActualStart
+			}
+			if got == nil || !got.Equal(tt.at) {
+				t.Errorf("marked at %v, want %s", got, tt.at)
+			}
+		})
+	}
+}
+
+func TestStatusTransitionError(t *testing.T) {
+	err := fmt.Errorf("%s: %w", "taskstorage.postgresql.UpdateTask",
+		&StatusTransitionError{From: models.TaskStatusComplete, To: models.TaskStatusQueue})
+
+	if !errors.Is(err, ErrStatusTransition) {
+		t.Errorf("errors.Is(%v, ErrStatusTransition) = false", err)
+	}
+	var transition *StatusTransitionError
+	if !errors.As(err, &transition) || transition.From != models.TaskStatusComplete || transition.To != models.TaskStatusQueue {
+		t.Errorf("errors.As(%v) = %+v", err, transition)
+	}
+	if want := `taskstorage.postgresql.UpdateTask: illegal status transition: from "complete" to "queue"`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
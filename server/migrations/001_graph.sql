@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS graph_vertices (
+    name TEXT PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS graph_edges (
+    from_vertex TEXT NOT NULL REFERENCES graph_vertices (name) ON DELETE CASCADE,
+    to_vertex   TEXT NOT NULL REFERENCES graph_vertices (name) ON DELETE CASCADE,
+    length      DOUBLE PRECISION NOT NULL CHECK (length >= 0), -- km
+    PRIMARY KEY (from_vertex, to_vertex)
+);
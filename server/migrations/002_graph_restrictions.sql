@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS graph_restrictions (
+    id          SERIAL PRIMARY KEY,
+    from_vertex TEXT NOT NULL,
+    to_vertex   TEXT NOT NULL,
+    time_start  TIMESTAMPTZ NOT NULL,
+    time_end    TIMESTAMPTZ NOT NULL CHECK (time_end > time_start),
+    max_speed   DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (max_speed >= 0), -- km/h, 0 - edge is closed
+    FOREIGN KEY (from_vertex, to_vertex) REFERENCES graph_edges (from_vertex, to_vertex) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+ALTER TABLE graph_vertices
+    ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS lat  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS lon  DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- NULL length is computed from the coordinates of the vertices
+ALTER TABLE graph_edges ALTER COLUMN length DROP NOT NULL;
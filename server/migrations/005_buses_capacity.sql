@@ -0,0 +1,6 @@
+ALTER TABLE buses
+    ADD COLUMN IF NOT EXISTS capacity   INT NOT NULL DEFAULT 30,
+    ADD COLUMN IF NOT EXISTS type       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS accessible BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS passengers INT NOT NULL DEFAULT 0;
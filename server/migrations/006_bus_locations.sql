@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS bus_locations (
+    id     BIGSERIAL PRIMARY KEY,
+    bus_id INT NOT NULL,
+    vertex TEXT NOT NULL DEFAULT '',
+    lat    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    lon    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    time   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS bus_locations_bus_id_time_idx ON bus_locations (bus_id, time DESC);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS breaks (
+    id          SERIAL PRIMARY KEY,
+    bus_id      INT NOT NULL,
+    time_start  TIMESTAMPTZ NOT NULL,
+    planned_end TIMESTAMPTZ NOT NULL,
+    time_end    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS breaks_bus_id_idx ON breaks (bus_id) WHERE time_end IS NULL;
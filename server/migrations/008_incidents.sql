@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS incidents (
+    id            SERIAL PRIMARY KEY,
+    bus_id        INT NOT NULL,
+    task_id       INT NOT NULL DEFAULT 0,
+    type          TEXT NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    status        TEXT NOT NULL DEFAULT 'open',
+    time_created  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    time_resolved TIMESTAMPTZ,
+    resolution    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS incidents_status_idx ON incidents (status, time_created DESC);
@@ -0,0 +1,20 @@
+CREATE OR REPLACE FUNCTION notify_flight_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('flight_changes', json_build_object(
+        'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END,
+        'op', TG_OP
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS flights_changed ON flights;
+CREATE TRIGGER flights_changed
+    AFTER INSERT OR DELETE ON flights
+    FOR EACH ROW EXECUTE FUNCTION notify_flight_change();
+
+DROP TRIGGER IF EXISTS flights_updated ON flights;
+CREATE TRIGGER flights_updated
+    AFTER UPDATE ON flights
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION notify_flight_change();
@@ -0,0 +1,20 @@
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'flights' AND column_name = 'destination') THEN
+        ALTER TABLE flights RENAME COLUMN destination TO stand;
+    END IF;
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'flights' AND column_name = 'time') THEN
+        ALTER TABLE flights RENAME COLUMN time TO scheduled_time;
+    END IF;
+END $$;
+
+ALTER TABLE flights
+    ADD COLUMN IF NOT EXISTS direction      CHAR(1) NOT NULL DEFAULT 'A',
+    ADD COLUMN IF NOT EXISTS gate           TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS aircraft_type  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS estimated_time TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS actual_time    TIMESTAMP;
+
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS pickup  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS dropoff TEXT NOT NULL DEFAULT '';
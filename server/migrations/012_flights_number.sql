@@ -0,0 +1,7 @@
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS number TEXT NOT NULL DEFAULT '';
+
+-- A flight number operates at most once a day in each direction, the importer
+-- relies on it to update flights instead of duplicating them.
+CREATE UNIQUE INDEX IF NOT EXISTS flights_number_day
+    ON flights (number, direction, (scheduled_time::date))
+    WHERE number != '';
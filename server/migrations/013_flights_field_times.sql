@@ -0,0 +1,3 @@
+-- When each field of a flight was last changed at the source, by column name.
+-- Updates from the airport operational database older than that are ignored.
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS field_times JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,22 @@
+CREATE OR REPLACE FUNCTION notify_task_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('task_changes', json_build_object(
+        'id', CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END,
+        'op', TG_OP,
+        'busID', CASE WHEN TG_OP = 'DELETE' THEN OLD.bus_id ELSE NEW.bus_id END,
+        'previousBusID', CASE WHEN TG_OP = 'INSERT' THEN NEW.bus_id ELSE OLD.bus_id END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_changed ON tasks;
+CREATE TRIGGER tasks_changed
+    AFTER INSERT OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION notify_task_change();
+
+DROP TRIGGER IF EXISTS tasks_updated ON tasks;
+CREATE TRIGGER tasks_updated
+    AFTER UPDATE ON tasks
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION notify_task_change();
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS accounts (
+    id            SERIAL PRIMARY KEY,
+    login         TEXT NOT NULL UNIQUE,
+    role          TEXT NOT NULL,
+    password_hash TEXT NOT NULL
+);
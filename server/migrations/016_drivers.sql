@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS drivers (
+    id   SERIAL PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+-- The roster: which driver drives which bus and when. Overlapping shifts of
+-- one driver or one bus are rejected by the driver storage.
+CREATE TABLE IF NOT EXISTS shifts (
+    id          SERIAL PRIMARY KEY,
+    driver_id   INT NOT NULL REFERENCES drivers (id) ON DELETE CASCADE,
+    bus_id      INT NOT NULL,
+    time_start  TIMESTAMPTZ NOT NULL,
+    time_end    TIMESTAMPTZ NOT NULL CHECK (time_end > time_start),
+    checked_in  TIMESTAMPTZ,
+    checked_out TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS shifts_driver_id_idx ON shifts (driver_id, time_start);
+CREATE INDEX IF NOT EXISTS shifts_bus_id_idx ON shifts (bus_id, time_start);
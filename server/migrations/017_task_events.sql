@@ -0,0 +1,64 @@
+-- The history of the tasks: who changed which field, when, from what to what.
+-- Rows are written by the trigger on tasks below and are never changed.
+CREATE TABLE IF NOT EXISTS task_events (
+    id        BIGSERIAL PRIMARY KEY,
+    task_id   INT NOT NULL,  -- no foreign key, the history outlives deleted tasks
+    time      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id   INT,           -- NULL for the scheduler
+    role      TEXT NOT NULL, -- driver, dispatcher, admin or scheduler
+    field     TEXT NOT NULL, -- created, deleted, status, bus_id, time_start, time_end or passengers
+    old_value TEXT,
+    new_value TEXT
+);
+
+CREATE INDEX IF NOT EXISTS task_events_task_id_idx ON task_events (task_id, id);
+
+CREATE OR REPLACE FUNCTION reject_task_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'task_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_events_append_only ON task_events;
+CREATE TRIGGER task_events_append_only
+    BEFORE UPDATE OR DELETE ON task_events
+    FOR EACH ROW EXECUTE FUNCTION reject_task_event_change();
+
+DROP TRIGGER IF EXISTS task_events_no_truncate ON task_events;
+CREATE TRIGGER task_events_no_truncate
+    BEFORE TRUNCATE ON task_events
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_task_event_change();
+
+-- The task storage sets tasks.user_id and tasks.role for the transaction of
+-- a change made by a user, changes without them are made by the scheduler.
+CREATE OR REPLACE FUNCTION record_task_change() RETURNS trigger AS $$
+DECLARE
+    actor_id   INT  := NULLIF(current_setting('tasks.user_id', true), '')::INT;
+    actor_role TEXT := COALESCE(NULLIF(current_setting('tasks.role', true), ''), 'scheduler');
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_events (task_id, user_id, role, field, new_value)
+            VALUES (NEW.id, actor_id, actor_role, 'created', NEW.status);
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO task_events (task_id, user_id, role, field, old_value)
+            VALUES (OLD.id, actor_id, actor_role, 'deleted', OLD.status);
+    ELSE
+        INSERT INTO task_events (task_id, user_id, role, field, old_value, new_value)
+        SELECT NEW.id, actor_id, actor_role, change.field, change.old_value, change.new_value
+        FROM (VALUES
+            ('status', OLD.status::TEXT, NEW.status::TEXT),
+            ('bus_id', OLD.bus_id::TEXT, NEW.bus_id::TEXT),
+            ('time_start', OLD.time_start::TEXT, NEW.time_start::TEXT),
+            ('time_end', OLD.time_end::TEXT, NEW.time_end::TEXT),
+            ('passengers', OLD.passengers::TEXT, NEW.passengers::TEXT)
+        ) AS change (field, old_value, new_value)
+        WHERE change.old_value IS DISTINCT FROM change.new_value;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_recorded ON tasks;
+CREATE TRIGGER tasks_recorded
+    AFTER INSERT OR UPDATE OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION record_task_change();
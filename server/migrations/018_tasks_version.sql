@@ -0,0 +1,17 @@
+-- The version of a task grows with every change, whoever makes it. Clients
+-- send it back in If-Match to change the task they have seen, see the ETag
+-- of the task endpoints.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_task_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_versioned ON tasks;
+CREATE TRIGGER tasks_versioned
+    BEFORE UPDATE ON tasks
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE FUNCTION bump_task_version();
@@ -0,0 +1,11 @@
+-- The date of the flight by schedule at its origin, together with the number
+-- and the direction it identifies the flight. Unlike scheduled_time it never
+-- changes, so a rescheduled flight is updated instead of duplicated.
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS flight_date DATE;
+
+UPDATE flights SET flight_date = scheduled_time::date WHERE flight_date IS NULL;
+
+DROP INDEX IF EXISTS flights_number_day;
+CREATE UNIQUE INDEX IF NOT EXISTS flights_number_date
+    ON flights (number, direction, flight_date)
+    WHERE number != '';
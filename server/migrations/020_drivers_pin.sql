@@ -0,0 +1,4 @@
+-- Drivers sign in with the id, the name and a PIN issued by the dispatcher.
+-- Only the bcrypt hash of the PIN is kept. Drivers added before have no PIN
+-- and cannot sign in until the dispatcher issues one.
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS pin_hash TEXT;